@@ -0,0 +1,337 @@
+// Package main - Entry point for the remote notification worker agent.
+//
+// agent dials a go_notifications server's /rpc WebSocket endpoint and
+// pulls jobs to run locally, modeled on Drone CI's agent/server split: the
+// server never reaches out to the agent, the agent always initiates the
+// connection, so it can run behind NAT or in a separate, less trusted
+// network than the server.
+//
+// Only "notification" jobs are supported - the agent has its own channel
+// configuration (it dispatches via the same channels.Dispatcher the
+// server uses, just built from a config file local to the agent) but no
+// access to the server's template store, job scheduler, or backup sink,
+// so "template", "shell", "webhook", "backup" and "restore" jobs are
+// reported back as failed rather than run.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/project-anarchy/go_notifications/internal/channels"
+	"github.com/project-anarchy/go_notifications/internal/config"
+	"github.com/project-anarchy/go_notifications/internal/queue"
+	"github.com/project-anarchy/go_notifications/internal/queue/rpc"
+)
+
+func main() {
+	endpoint := flag.String("server", os.Getenv("WS_ENDPOINT"), "ws:// or wss:// URL of the server's /rpc endpoint")
+	secret := flag.String("secret", os.Getenv("NOTIFY_RPC_SECRET"), "shared secret to authenticate with the server")
+	workerID := flag.String("worker-id", "", "identifies this agent to the server; defaults to hostname-pid")
+	tagsFlag := flag.String("tags", "", "comma-separated key=value capability tags this agent declares, e.g. region=us,gpu=true")
+	configPath := flag.String("config", "config.yaml", "path to the channel configuration this agent dispatches notifications with")
+	maxProcs := flag.Int("max-procs", 1, "maximum number of jobs this agent runs concurrently")
+	retryLimit := flag.Int("retry-limit", 0, "give up reconnecting after this many consecutive failures (0 = retry forever)")
+	allowPlaintextSecrets := flag.Bool("allow-plaintext-secrets", false, "allow unencrypted values for secret config fields (api_key, slack_webhook, smtp.password) instead of requiring \"!enc\"")
+	flag.Parse()
+
+	if *endpoint == "" {
+		log.Fatal("agent: -server (or WS_ENDPOINT) is required")
+	}
+	if *secret == "" {
+		log.Fatal("agent: -secret (or NOTIFY_RPC_SECRET) is required")
+	}
+	if *workerID == "" {
+		host, _ := os.Hostname()
+		*workerID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	cfg, err := config.Load(*configPath, *allowPlaintextSecrets)
+	if err != nil {
+		log.Printf("agent: could not load %s, using defaults: %v", *configPath, err)
+		cfg = config.Default()
+	}
+	dispatcher := buildDispatcher(cfg)
+	tags := parseTags(*tagsFlag)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-quit
+		log.Println("agent: shutting down, draining in-flight jobs...")
+		cancel()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < *maxProcs; i++ {
+		wg.Add(1)
+		go func(slot int) {
+			defer wg.Done()
+			runSlot(ctx, slotWorkerID(*workerID, slot, *maxProcs), *endpoint, *secret, tags, dispatcher, *retryLimit)
+		}(i)
+	}
+	wg.Wait()
+	log.Println("agent: exited")
+}
+
+// slotWorkerID gives each concurrent slot its own identity when max-procs
+// > 1, so the server's acquired_by column distinguishes them.
+func slotWorkerID(base string, slot, total int) string {
+	if total <= 1 {
+		return base
+	}
+	return fmt.Sprintf("%s-%d", base, slot)
+}
+
+func parseTags(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, pair := range splitNonEmpty(s, ",") {
+		k, v, ok := cut(pair, "=")
+		if ok {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+func splitNonEmpty(s, sep string) []string {
+	var out []string
+	for _, part := range split(s, sep) {
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// split and cut are tiny local helpers so this file doesn't need to import
+// strings for two one-line operations.
+func split(s, sep string) []string {
+	var out []string
+	start := 0
+	for i := 0; i+len(sep) <= len(s); i++ {
+		if s[i:i+len(sep)] == sep {
+			out = append(out, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	out = append(out, s[start:])
+	return out
+}
+
+func cut(s, sep string) (before, after string, found bool) {
+	for i := 0; i+len(sep) <= len(s); i++ {
+		if s[i:i+len(sep)] == sep {
+			return s[:i], s[i+len(sep):], true
+		}
+	}
+	return s, "", false
+}
+
+// buildDispatcher wires up the same channel set cmd/server builds, through
+// the same Notifier factory registry, limited to channels whose required
+// config fields are present - an agent dispatching only email, say, just
+// omits the rest from its config.
+func buildDispatcher(cfg *config.Config) *channels.Dispatcher {
+	notifiers := channels.NewNotifierRegistry()
+
+	emailCfg, _ := json.Marshal(channels.EmailConfig{
+		Host:               cfg.SMTP.Host,
+		Port:               cfg.SMTP.Port,
+		Username:           cfg.SMTP.Username,
+		Password:           cfg.SMTP.Password.Reveal(),
+		From:               cfg.SMTP.From,
+		UseTLS:             cfg.SMTP.UseTLS,
+		InsecureSkipVerify: cfg.SMTP.InsecureSkipVerify,
+		Transport:          cfg.SMTP.Transport,
+		SendmailPath:       cfg.SMTP.SendmailPath,
+	})
+	notifiers.Build("email", emailCfg)
+
+	webhookCfg, _ := json.Marshal(channels.WebhookConfig{
+		Timeout:        cfg.WebhookTimeout,
+		SigningSecrets: cfg.WebhookDelivery.SigningSecrets,
+	})
+	notifiers.Build("webhook", webhookCfg)
+	webhookNotifier, _ := notifiers.Get("webhook")
+	webhookChannel := channels.WebhookChannelOf(webhookNotifier)
+
+	slackCfg, _ := json.Marshal(channels.SlackConfig{WebhookURL: cfg.SlackWebhook.Reveal()})
+	notifiers.Build("slack", slackCfg)
+
+	fileCfg, _ := json.Marshal(channels.FileConfig{LogDir: cfg.LogDir})
+	notifiers.Build("file", fileCfg)
+
+	// The agent has no SMS/Telegram/Discord/push config of its own, so the
+	// legacy registry it falls back to is always empty.
+	registry := channels.NewRegistry()
+
+	return channels.NewDispatcher(notifiers, registry, webhookChannel)
+}
+
+// runSlot owns one WebSocket connection to the server: it reconnects with
+// exponential backoff (capped at 30s) on any disconnect, giving up after
+// retryLimit consecutive failures (0 = never give up), and otherwise loops
+// Next -> dispatch -> Done until ctx is cancelled.
+func runSlot(ctx context.Context, workerID, endpoint, secret string, tags map[string]string, dispatcher *channels.Dispatcher, retryLimit int) {
+	failures := 0
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		conn, err := dial(endpoint, secret)
+		if err != nil {
+			failures++
+			if retryLimit > 0 && failures >= retryLimit {
+				log.Printf("agent[%s]: giving up after %d consecutive connect failures: %v", workerID, failures, err)
+				return
+			}
+			backoff := 30 * time.Second
+			if failures <= 5 {
+				backoff = time.Second << uint(failures-1)
+			}
+			log.Printf("agent[%s]: connect failed (%v), retrying in %s", workerID, err, backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		failures = 0
+
+		c := &client{conn: conn}
+		runJobLoop(ctx, c, workerID, tags, dispatcher)
+		conn.Close()
+	}
+}
+
+func dial(endpoint, secret string) (*websocket.Conn, error) {
+	header := http.Header{}
+	header.Set("X-RPC-Secret", secret)
+	conn, _, err := websocket.DefaultDialer.Dial(endpoint, header)
+	return conn, err
+}
+
+// runJobLoop pulls and runs jobs over one already-authenticated connection
+// until it errors or ctx is cancelled.
+func runJobLoop(ctx context.Context, c *client, workerID string, tags map[string]string, dispatcher *channels.Dispatcher) {
+	for ctx.Err() == nil {
+		var next rpc.NextResult
+		if err := c.call(rpc.MethodNext, rpc.NextParams{WorkerID: workerID, Tags: tags}, &next); err != nil {
+			log.Printf("agent[%s]: Next failed: %v", workerID, err)
+			return
+		}
+		if next.Empty || next.Job == nil {
+			continue
+		}
+
+		runJob(ctx, c, workerID, next.Job, dispatcher)
+	}
+}
+
+// extendInterval renews a job's lease well before DefaultLeaseTTL expires,
+// so a job that legitimately runs long doesn't get reaped out from under
+// its agent.
+const extendInterval = 1 * time.Minute
+
+func runJob(ctx context.Context, c *client, workerID string, job *queue.Job, dispatcher *channels.Dispatcher) {
+	log.Printf("agent[%s]: running job %s (type=%s)", workerID, job.ID, job.Type)
+
+	if err := c.call(rpc.MethodUpdate, rpc.UpdateParams{JobID: job.ID, WorkerID: workerID, Status: "running"}, nil); err != nil {
+		log.Printf("agent[%s]: Update(running) failed: %v", workerID, err)
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(extendInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				var res rpc.ExtendResult
+				if err := c.call(rpc.MethodExtend, rpc.ExtendParams{JobID: job.ID, WorkerID: workerID}, &res); err != nil || !res.Ok {
+					log.Printf("agent[%s]: lease on job %s lost: err=%v ok=%v", workerID, job.ID, err, res.Ok)
+				}
+			}
+		}
+	}()
+
+	result, jobErr := dispatchJob(job, dispatcher)
+
+	done := rpc.DoneParams{JobID: job.ID, WorkerID: workerID, Status: "completed", Result: result}
+	if jobErr != nil {
+		done.Status = "failed"
+		done.Error = jobErr.Error()
+		log.Printf("agent[%s]: job %s failed: %v", workerID, job.ID, jobErr)
+	}
+	if err := c.call(rpc.MethodDone, done, nil); err != nil {
+		log.Printf("agent[%s]: Done failed for job %s: %v", workerID, job.ID, err)
+	}
+}
+
+func dispatchJob(job *queue.Job, dispatcher *channels.Dispatcher) (map[string]interface{}, error) {
+	switch job.Type {
+	case "notification", "":
+		if job.Notification == nil {
+			return nil, fmt.Errorf("notification job %s has no notification payload", job.ID)
+		}
+		return dispatcher.Dispatch(job.Notification)
+	default:
+		return nil, fmt.Errorf("job type %q is not supported by this agent", job.Type)
+	}
+}
+
+// client is a minimal synchronous JSON-RPC 2.0 client: one call in flight
+// at a time. call holds mu for the whole request/response round trip,
+// since runJob's lease-renewal goroutine may call Extend while that same
+// job's Update or Done call is in progress.
+type client struct {
+	mu     sync.Mutex
+	conn   *websocket.Conn
+	nextID int64
+}
+
+func (c *client) call(method string, params, result interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	paramsRaw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	req := rpc.Request{JSONRPC: "2.0", ID: c.nextID, Method: method, Params: paramsRaw}
+	if err := c.conn.WriteJSON(req); err != nil {
+		return err
+	}
+
+	var resp rpc.Response
+	if err := c.conn.ReadJSON(&resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, resp.Error.Message, resp.Error.Code)
+	}
+	if result != nil && resp.Result != nil {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"log"
 	"net/http"
 	"os"
@@ -13,16 +15,23 @@ import (
 	"time"
 
 	"github.com/project-anarchy/go_notifications/internal/api"
+	"github.com/project-anarchy/go_notifications/internal/auditlog"
+	"github.com/project-anarchy/go_notifications/internal/auth"
+	"github.com/project-anarchy/go_notifications/internal/callbacks"
 	"github.com/project-anarchy/go_notifications/internal/channels"
 	"github.com/project-anarchy/go_notifications/internal/config"
 	"github.com/project-anarchy/go_notifications/internal/queue"
+	"github.com/project-anarchy/go_notifications/internal/queue/rpc"
 	"github.com/project-anarchy/go_notifications/internal/storage"
 	"github.com/project-anarchy/go_notifications/internal/templates"
 )
 
 func main() {
+	allowPlaintextSecrets := flag.Bool("allow-plaintext-secrets", false, "allow unencrypted values for secret config fields (api_key, slack_webhook, smtp.password) instead of requiring \"!enc\"")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.Load("config.yaml")
+	cfg, err := config.Load("config.yaml", *allowPlaintextSecrets)
 	if err != nil {
 		log.Printf("Warning: Could not load config file, using defaults: %v", err)
 		cfg = config.Default()
@@ -35,33 +44,260 @@ func main() {
 	}
 	defer store.Close()
 
-	// Initialize template renderer
+	// Initialize template renderer and the sandboxed named-template store
+	// used by /api/notify/template and the template management endpoints.
 	renderer := templates.NewRenderer(cfg.TemplatesDir)
+	fsTemplateStore := templates.NewFSTemplateStore(cfg.TemplatesDir)
+	var templateStore templates.TemplateStore = fsTemplateStore
+
+	if cfg.TemplateWatch {
+		watchingRenderer, err := templates.NewWatchingRenderer(renderer)
+		if err != nil {
+			log.Printf("Warning: template hot-reload disabled: %v", err)
+		} else {
+			defer watchingRenderer.Close()
+		}
+
+		watchingStore, err := templates.NewWatchingTemplateStore(fsTemplateStore)
+		if err != nil {
+			log.Printf("Warning: template store hot-reload disabled: %v", err)
+		} else {
+			templateStore = watchingStore
+			defer watchingStore.Close()
+		}
+	}
+
+	// Slack, webhook, email, and file are built from their own typed
+	// config structs through the Notifier factory registry rather than
+	// constructed and registered by hand, so a new deployment can add or
+	// swap one of these by registering a factory instead of editing this
+	// function.
+	notifiers := channels.NewNotifierRegistry()
+
+	emailCfg, _ := json.Marshal(channels.EmailConfig{
+		Host:               cfg.SMTP.Host,
+		Port:               cfg.SMTP.Port,
+		Username:           cfg.SMTP.Username,
+		Password:           cfg.SMTP.Password.Reveal(),
+		From:               cfg.SMTP.From,
+		UseTLS:             cfg.SMTP.UseTLS,
+		InsecureSkipVerify: cfg.SMTP.InsecureSkipVerify,
+		Transport:          cfg.SMTP.Transport,
+		SendmailPath:       cfg.SMTP.SendmailPath,
+	})
+	if err := notifiers.Build("email", emailCfg); err != nil {
+		log.Fatalf("Failed to initialize email notifier: %v", err)
+	}
+
+	webhookCfg, _ := json.Marshal(channels.WebhookConfig{
+		Timeout:        cfg.WebhookTimeout,
+		SigningSecrets: cfg.WebhookDelivery.SigningSecrets,
+	})
+	if err := notifiers.Build("webhook", webhookCfg); err != nil {
+		log.Fatalf("Failed to initialize webhook notifier: %v", err)
+	}
+	webhookNotifier, _ := notifiers.Get("webhook")
+	webhookChannel := channels.WebhookChannelOf(webhookNotifier)
+
+	slackCfg, _ := json.Marshal(channels.SlackConfig{WebhookURL: cfg.SlackWebhook.Reveal()})
+	if err := notifiers.Build("slack", slackCfg); err != nil {
+		log.Fatalf("Failed to initialize slack notifier: %v", err)
+	}
+	if cfg.SlackBotToken != "" {
+		slackNotifier, _ := notifiers.Get("slack")
+		if slackChannel := channels.SlackChannelOf(slackNotifier); slackChannel != nil {
+			slackChannel.SetAPIClient(channels.NewSlackAPIClient(cfg.SlackBotToken))
+		}
+	}
+
+	fileCfg, _ := json.Marshal(channels.FileConfig{LogDir: cfg.LogDir})
+	if err := notifiers.Build("file", fileCfg); err != nil {
+		log.Fatalf("Failed to initialize file notifier: %v", err)
+	}
+
+	// Every other provider (SMS, Telegram, Discord, push, script, ...)
+	// stays on the older Channel-based Registry until it's migrated to a
+	// Notifier of its own; Dispatcher checks notifiers first, then
+	// registry.
+	registry := channels.NewRegistry()
 
-	// Initialize notification channels
-	emailChannel := channels.NewEmailChannel(cfg.SMTP)
-	webhookChannel := channels.NewWebhookChannel(cfg.WebhookTimeout)
-	slackChannel := channels.NewSlackChannel(cfg.SlackWebhook)
-	fileChannel := channels.NewFileChannel(cfg.LogDir)
+	// ScriptChannel's hooksDir comes from Security config, so (unlike the
+	// other channels' Parse methods) it can't self-register a zero-value
+	// receiver from an init() - it's wired here once cfg is loaded.
+	if cfg.Security.HooksEnabled {
+		scriptChannel := channels.NewScriptChannel(cfg.Security.HooksDir)
+		registry.Register("script", scriptChannel)
+		channels.Register("script", scriptChannel.Parse)
+	}
+
+	// Optional providers: each is only registered once its required
+	// config fields are present, so an unconfigured provider simply
+	// isn't reachable rather than failing startup.
+	if cfg.SMS.AccountSID != "" {
+		smsChannel, err := channels.NewSMSChannel(channels.SMSConfig{
+			AccountSID: cfg.SMS.AccountSID,
+			AuthToken:  cfg.SMS.AuthToken,
+			From:       cfg.SMS.From,
+			BaseURL:    cfg.SMS.BaseURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize SMS channel: %v", err)
+		}
+		registry.Register("sms", smsChannel)
+	}
+	if cfg.Telegram.BotToken != "" {
+		telegramChannel, err := channels.NewTelegramChannel(channels.TelegramConfig{
+			BotToken: cfg.Telegram.BotToken,
+			BaseURL:  cfg.Telegram.BaseURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize Telegram channel: %v", err)
+		}
+		registry.Register("telegram", telegramChannel)
+	}
+	if cfg.Discord.WebhookURL != "" {
+		discordChannel, err := channels.NewDiscordChannel(channels.DiscordConfig{
+			WebhookURL: cfg.Discord.WebhookURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize Discord channel: %v", err)
+		}
+		registry.Register("discord", discordChannel)
+	}
+	if cfg.Push.ServerKey != "" {
+		pushChannel, err := channels.NewPushChannel(channels.PushConfig{
+			ServerKey: cfg.Push.ServerKey,
+			Endpoint:  cfg.Push.Endpoint,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize push channel: %v", err)
+		}
+		registry.Register("push", pushChannel)
+	}
 
 	// Create channel dispatcher
-	dispatcher := channels.NewDispatcher(
-		emailChannel,
-		webhookChannel,
-		slackChannel,
-		fileChannel,
-	)
+	dispatcher := channels.NewDispatcher(notifiers, registry, webhookChannel)
+	for name, webhookURL := range cfg.SlackWorkspaces {
+		dispatcher.RegisterSlackWorkspace(name, webhookURL)
+	}
+	rateLimiter := channels.NewRateLimiter(cfg.ChannelRateLimits)
 
 	// Initialize background queue worker
 	worker := queue.NewWorker(store, dispatcher, renderer, cfg.WorkerCount)
+	worker.SetMaxConcurrent("shell", 2) // shell jobs are the slowest; cap so they can't starve other types
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 	go worker.Start(workerCtx)
 
+	// Initialize the durable webhook delivery worker: retries failed
+	// webhook sends with backoff+jitter, dead-lettering them once
+	// cfg.WebhookDelivery.MaxAttempts is exhausted.
+	webhookDelivery := queue.NewWebhookDeliveryWorker(store, dispatcher, queue.WebhookDeliveryConfig{
+		BaseDelay:    cfg.WebhookDelivery.RetryBaseDelay,
+		MaxDelay:     cfg.WebhookDelivery.RetryMaxDelay,
+		MaxAttempts:  cfg.WebhookDelivery.MaxAttempts,
+		PollInterval: cfg.WebhookDelivery.PollInterval,
+	})
+	go webhookDelivery.Start(workerCtx)
+
+	// Build one callback.Verifier per configured callback id, so
+	// ProcessCallback can verify /api/callback/{id} against that id's own
+	// provider format. Each Verifier's SecretsFunc reads the current
+	// secret out of store.CallbackSecrets() on every request, so rotating
+	// one via RotateCallbackSecret takes effect immediately.
+	callbackVerifiers := make(map[string]*callbacks.Verifier, len(cfg.Callbacks))
+	for id, cbCfg := range cfg.Callbacks {
+		callbackID := id
+		callbackVerifiers[callbackID] = callbacks.NewVerifier(callbacks.Config{
+			Provider: callbacks.Provider(cbCfg.Provider),
+			SecretsFunc: func() ([]string, error) {
+				secret, err := store.CallbackSecrets().Get(context.Background(), callbackID)
+				if err != nil {
+					return nil, err
+				}
+				return []string{secret.Secret}, nil
+			},
+		}, 0)
+	}
+
+	// Named handlers ProcessCallback runs once a callback id's signature
+	// verifies, replacing the old X-Callback-Hook header (which ran
+	// whatever shell command the caller supplied). No handlers are
+	// registered yet; an id with none just gets logged and acknowledged.
+	callbackHandlers := callbacks.NewRegistry()
+
 	// Initialize API handlers
-	handlers := api.NewHandlers(store, dispatcher, renderer, worker)
+	handlers := api.NewHandlers(store, dispatcher, renderer, templateStore, worker, rateLimiter, webhookDelivery, callbackVerifiers, callbackHandlers)
+
+	// Initialize the remote worker protocol (queue/rpc): remote cmd/agent
+	// processes dial /rpc and pull jobs instead of running them in this
+	// process, alongside (not instead of) the in-process queue.Worker.
+	var rpcHandler http.Handler
+	if cfg.RPC.Enabled {
+		rpcServer := rpc.NewServer(store, cfg.RPC.SharedSecret, cfg.RPC.LeaseTTL)
+		reaper := rpc.NewReaper(store, cfg.RPC.ReapInterval)
+		go reaper.Start(workerCtx)
+		rpcHandler = rpcServer
+	}
+
+	// Build the named authenticator registry Config.Auth.Routes draws
+	// chains from. "apikey" seeds itself from cfg.APIKey on first run (if
+	// the api_keys table is still empty) so upgrading from the old static
+	// Config.APIKey doesn't lock operators out.
+	if cfg.Auth.APIKeysEnabled {
+		if existing, err := store.APIKeys().ListActive(context.Background()); err == nil && len(existing) == 0 && cfg.APIKey.Reveal() != "" {
+			if err := store.APIKeys().Rotate(context.Background(), "default", auth.HashAPIKey(cfg.APIKey.Reveal()), []string{cfg.Auth.AdminRole}); err != nil {
+				log.Printf("auth: failed to seed default API key: %v", err)
+			}
+		}
+	}
+
+	authRegistry := map[string]auth.Authenticator{}
+	if cfg.Auth.APIKeysEnabled {
+		authRegistry["apikey"] = auth.NewAPIKeyAuthenticator(func() ([]auth.HashedKey, error) {
+			keys, err := store.APIKeys().ListActive(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			hashedKeys := make([]auth.HashedKey, len(keys))
+			for i, k := range keys {
+				hashedKeys[i] = auth.HashedKey{ID: k.ID, Hash: k.Hash, Roles: k.Roles}
+			}
+			return hashedKeys, nil
+		})
+	}
+	if cfg.Auth.Basic.Enabled {
+		authRegistry["basic"] = auth.NewBasicAuthenticator(cfg.Auth.Basic.HtpasswdPath, nil)
+	}
+	if cfg.Auth.OIDC.Enabled {
+		authRegistry["oidc"] = auth.NewOIDCAuthenticator(auth.OIDCConfig{
+			Issuer:    cfg.Auth.OIDC.Issuer,
+			JWKSURL:   cfg.Auth.OIDC.JWKSURL,
+			Audience:  cfg.Auth.OIDC.Audience,
+			RoleClaim: cfg.Auth.OIDC.RoleClaim,
+			CacheTTL:  cfg.Auth.OIDC.CacheTTL,
+		})
+	}
 
-	// Setup router
-	router := api.NewRouter(handlers, cfg.APIKey)
+	apiAuth, err := auth.BuildChain(cfg.Auth.Routes["api"], authRegistry)
+	if err != nil {
+		log.Fatalf("auth: %v", err)
+	}
+
+	// Setup router. requestLogger always runs; auditLogger only runs
+	// behind cfg.Security.EnableAuditLog - both rotate per their own
+	// auditlog.Config rather than growing an unbounded file.
+	requestLogger := auditlog.New(cfg.Security.RequestLog)
+	auditLogger := auditlog.New(cfg.Security.AuditLog)
+	corsCfg := api.CORSConfig{
+		AllowedOrigins:   cfg.Security.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.Security.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.Security.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.Security.CORS.ExposedHeaders,
+		AllowCredentials: cfg.Security.CORS.AllowCredentials,
+		MaxAge:           cfg.Security.CORS.MaxAge,
+	}
+	router := api.NewRouter(handlers, apiAuth, cfg.Auth.AdminRole, rpcHandler, requestLogger, auditLogger, cfg.Security.EnableAuditLog,
+		cfg.Security.RateLimitPerMin, cfg.Security.RateLimitBurst, cfg.Security.TrustedProxies, corsCfg, cfg.Security.AllowedHosts)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -79,11 +315,22 @@ func main() {
 		log.Printf("  POST /api/notify          - Send immediate notification")
 		log.Printf("  POST /api/notify/batch    - Queue batch notifications")
 		log.Printf("  POST /api/notify/template - Send templated notification")
+		log.Printf("  GET  /api/templates       - List templates")
+		log.Printf("  POST /api/templates/:name/preview - Preview a template")
+		log.Printf("  GET  /api/notifications/dead-letter - List dead-lettered webhooks")
+		log.Printf("  POST /api/notifications/dead-letter/:id/replay - Replay a dead-lettered webhook")
 		log.Printf("  GET  /api/notifications   - List notifications")
 		log.Printf("  GET  /api/health          - Health check")
 		log.Printf("  POST /api/webhook/test    - Test webhook delivery")
 		log.Printf("  POST /api/hooks/execute   - Execute shell hook")
 		log.Printf("  GET  /api/logs/:filename  - Read log file")
+		if cfg.RPC.Enabled {
+			log.Printf("  WS   /rpc                 - Remote worker protocol (queue/rpc)")
+		}
+		log.Printf("  POST /api/admin/templates/:name/reload - Evict a template's compiled cache entry")
+		if cfg.TemplateWatch {
+			log.Printf("  templates_dir is watched for changes (template_watch: true)")
+		}
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
@@ -3,37 +3,72 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/project-anarchy/go_notifications/internal/callbacks"
 	"github.com/project-anarchy/go_notifications/internal/channels"
+	"github.com/project-anarchy/go_notifications/internal/exporters"
+	"github.com/project-anarchy/go_notifications/internal/httpapi"
 	"github.com/project-anarchy/go_notifications/internal/queue"
 	"github.com/project-anarchy/go_notifications/internal/storage"
+	"github.com/project-anarchy/go_notifications/internal/storage/notificationrepo"
 	"github.com/project-anarchy/go_notifications/internal/templates"
-	"github.com/project-anarchy/go_notifications/pkg/utils"
+	"github.com/project-anarchy/go_notifications/pkg/safehttp"
 )
 
 // Handlers holds all HTTP handler dependencies
 type Handlers struct {
-	store      *storage.SQLiteStore
-	dispatcher *channels.Dispatcher
-	renderer   *templates.Renderer
-	worker     *queue.Worker
+	store             *storage.SQLiteStore
+	dispatcher        *channels.Dispatcher
+	renderer          *templates.Renderer
+	templateEngine    *templates.Engine
+	templateStore     templates.TemplateStore
+	worker            *queue.Worker
+	rateLimiter       *channels.RateLimiter
+	webhookDelivery   *queue.WebhookDeliveryWorker
+	callbackVerifiers map[string]*callbacks.Verifier
+	callbackHandlers  *callbacks.Registry
+
+	// proxyClient backs ProxyRequest; its safehttp.Policy allows plain
+	// HTTP (proxied targets are rarely HTTPS-only internal dashboards)
+	// but still blocks internal/loopback/link-local destinations.
+	proxyClient *safehttp.Client
 }
 
-// NewHandlers creates a new Handlers instance
-func NewHandlers(store *storage.SQLiteStore, dispatcher *channels.Dispatcher, renderer *templates.Renderer, worker *queue.Worker) *Handlers {
+// NewHandlers creates a new Handlers instance. templateStore backs both
+// /notify/template rendering and the template management endpoints.
+// webhookDelivery takes over a webhook notification's retries after
+// SendNotification's first, synchronous delivery attempt fails.
+// callbackVerifiers is keyed by the {id} ProcessCallback's route uses; an
+// id with no entry is rejected. callbackHandlers is consulted after
+// verification succeeds to run that id's registered post-processing, if
+// any.
+func NewHandlers(store *storage.SQLiteStore, dispatcher *channels.Dispatcher, renderer *templates.Renderer, templateStore templates.TemplateStore, worker *queue.Worker, rateLimiter *channels.RateLimiter, webhookDelivery *queue.WebhookDeliveryWorker, callbackVerifiers map[string]*callbacks.Verifier, callbackHandlers *callbacks.Registry) *Handlers {
+	proxyPolicy := safehttp.DefaultPolicy("proxy")
+	proxyPolicy.AllowedSchemes = []string{"http", "https"}
+
 	return &Handlers{
-		store:      store,
-		dispatcher: dispatcher,
-		renderer:   renderer,
-		worker:     worker,
+		store:             store,
+		dispatcher:        dispatcher,
+		renderer:          renderer,
+		templateEngine:    templates.NewEngine(templateStore),
+		templateStore:     templateStore,
+		worker:            worker,
+		rateLimiter:       rateLimiter,
+		webhookDelivery:   webhookDelivery,
+		callbackVerifiers: callbackVerifiers,
+		callbackHandlers:  callbackHandlers,
+		proxyClient:       safehttp.NewClient(proxyPolicy),
 	}
 }
 
@@ -70,7 +105,7 @@ func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) SendNotification(w http.ResponseWriter, r *http.Request) {
 	var req NotificationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		respondError(w, r, http.StatusBadRequest, "Invalid request body: "+err.Error())
 		return
 	}
 
@@ -88,7 +123,7 @@ func (h *Handlers) SendNotification(w http.ResponseWriter, r *http.Request) {
 		// TAINT FLOW: req.Template (user input) -> renderer.Render
 		rendered, err := h.renderer.Render(req.Template, req.Data)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "Template error: "+err.Error())
+			respondError(w, r, http.StatusBadRequest, "Template error: "+err.Error())
 			return
 		}
 		notification.Message = rendered
@@ -97,7 +132,7 @@ func (h *Handlers) SendNotification(w http.ResponseWriter, r *http.Request) {
 	// Store notification in database
 	id, err := h.store.SaveNotification(notification)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to save notification")
+		respondError(w, r, http.StatusInternalServerError, "Failed to save notification")
 		return
 	}
 	notification.ID = id
@@ -106,7 +141,19 @@ func (h *Handlers) SendNotification(w http.ResponseWriter, r *http.Request) {
 	result, err := h.dispatcher.Dispatch(notification)
 	if err != nil {
 		h.store.UpdateStatus(id, "failed", err.Error())
-		respondError(w, http.StatusInternalServerError, "Dispatch failed: "+err.Error())
+
+		// Webhook deliveries aren't dropped on the floor: hand the
+		// first failed attempt to webhookDelivery so it retries with
+		// backoff and eventually dead-letters it instead.
+		if notification.Channel == "webhook" {
+			h.webhookDelivery.ScheduleRetry(r.Context(), notification, 1, err)
+		}
+
+		if errors.Is(err, channels.ErrUnknownChannel) {
+			respondError(w, r, http.StatusBadRequest, "Dispatch failed: "+err.Error())
+			return
+		}
+		respondError(w, r, http.StatusInternalServerError, "Dispatch failed: "+err.Error())
 		return
 	}
 
@@ -123,13 +170,21 @@ func (h *Handlers) SendNotification(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) SendBatchNotification(w http.ResponseWriter, r *http.Request) {
 	var req BatchNotificationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, r, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	results := make([]map[string]interface{}, 0, len(req.Notifications))
 
 	for _, notif := range req.Notifications {
+		if !h.rateLimiter.Allow(notif.Channel) {
+			results = append(results, map[string]interface{}{
+				"status":  "rate_limited",
+				"channel": notif.Channel,
+			})
+			continue
+		}
+
 		notification := &channels.Notification{
 			Channel:   notif.Channel,
 			Recipient: notif.Recipient,
@@ -167,46 +222,93 @@ func (h *Handlers) SendBatchNotification(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-// SendTemplatedNotification renders a template and sends notification
-// TAINT SOURCE: Template name and data from request
+// SendTemplatedNotification renders a named, locale-aware template
+// through templateEngine (sandboxed FuncMap, render timeout, output
+// cap) and sends the result. Subject is only taken from the request if
+// the template doesn't define one.
 func (h *Handlers) SendTemplatedNotification(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Template  string                 `json:"template"`
 		Channel   string                 `json:"channel"`
 		Recipient string                 `json:"recipient"`
 		Subject   string                 `json:"subject"`
+		Locale    string                 `json:"locale"`
 		Data      map[string]interface{} `json:"data"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request")
+		respondError(w, r, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
-	// VULN: Template path traversal possible
-	// req.Template could be "../../../etc/passwd"
-	rendered, err := h.renderer.Render(req.Template, req.Data)
+	rendered, err := h.templateEngine.Render(r.Context(), req.Template, req.Locale, req.Channel, req.Data)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("Template render failed: %v", err))
+		respondError(w, r, http.StatusBadRequest, fmt.Sprintf("Template render failed: %v", err))
 		return
 	}
 
+	subject := rendered.Subject
+	if subject == "" {
+		subject = req.Subject
+	}
+
 	notification := &channels.Notification{
 		Channel:   req.Channel,
 		Recipient: req.Recipient,
-		Subject:   req.Subject,
-		Message:   rendered, // TAINT SINK: Rendered template with user data
+		Subject:   subject,
+		Message:   rendered.Body,
+		Locale:    req.Locale,
 	}
 
 	result, err := h.dispatcher.Dispatch(notification)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		if errors.Is(err, channels.ErrUnknownChannel) {
+			respondError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, result)
 }
 
+// ListTemplates returns the names of every available template.
+func (h *Handlers) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	names, err := h.templateStore.List(r.Context())
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to list templates: %v", err))
+		return
+	}
+	respondJSON(w, http.StatusOK, names)
+}
+
+// PreviewTemplate renders a named template against request body data
+// without dispatching a notification, so authors can check a template
+// before using it.
+func (h *Handlers) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var req struct {
+		Channel string                 `json:"channel"`
+		Locale  string                 `json:"locale"`
+		Data    map[string]interface{} `json:"data"`
+	}
+	if r.Body != nil {
+		// Preview data is optional; an empty/absent body just renders
+		// with no data.
+		_ = json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	rendered, err := h.templateEngine.Render(r.Context(), name, req.Locale, req.Channel, req.Data)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, fmt.Sprintf("Template render failed: %v", err))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, rendered)
+}
+
 // ListNotifications returns stored notifications with filtering
 // TAINT SOURCE: Query parameters
 func (h *Handlers) ListNotifications(w http.ResponseWriter, r *http.Request) {
@@ -220,15 +322,18 @@ func (h *Handlers) ListNotifications(w http.ResponseWriter, r *http.Request) {
 	// TAINT FLOW: Query params -> SQL query
 	notifications, err := h.store.ListNotifications(channel, status, recipient, limit, orderBy)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Query failed: "+err.Error())
+		respondError(w, r, http.StatusInternalServerError, "Query failed: "+err.Error())
 		return
 	}
 
 	respondJSON(w, http.StatusOK, notifications)
 }
 
-// TestWebhook sends a test webhook to a user-provided URL
-// TAINT SOURCE: URL from request body - SSRF vulnerability
+// TestWebhook sends a test webhook to a user-provided URL. The URL is
+// still taken verbatim from the request body, but SendToURL validates it
+// with ValidateWebhookURL and sends through a client built by
+// NewSecureHTTPClient, so internal destinations (169.254.169.254, Redis
+// on localhost, etc) are rejected before any request is made.
 func (h *Handlers) TestWebhook(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		URL     string            `json:"url"`
@@ -238,13 +343,10 @@ func (h *Handlers) TestWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request")
+		respondError(w, r, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
-	// VULN: SSRF - No URL validation, internal network accessible
-	// User can provide http://169.254.169.254/latest/meta-data/ (AWS)
-	// Or http://localhost:6379/ (Redis) etc.
 	result, err := h.dispatcher.WebhookChannel().SendToURL(
 		req.URL,     // TAINT SINK: User-controlled URL
 		req.Method,
@@ -252,7 +354,7 @@ func (h *Handlers) TestWebhook(w http.ResponseWriter, r *http.Request) {
 		req.Body,    // TAINT: User-controlled body
 	)
 	if err != nil {
-		respondError(w, http.StatusBadGateway, "Webhook failed: "+err.Error())
+		respondError(w, r, http.StatusBadGateway, "Webhook failed: "+err.Error())
 		return
 	}
 
@@ -269,7 +371,7 @@ func (h *Handlers) ExecuteHook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request")
+		respondError(w, r, http.StatusBadRequest, "Invalid request")
 		return
 	}
 
@@ -281,7 +383,7 @@ func (h *Handlers) ExecuteHook(w http.ResponseWriter, r *http.Request) {
 
 	// Check if hook exists
 	if _, err := os.Stat(hookPath); os.IsNotExist(err) {
-		respondError(w, http.StatusNotFound, "Hook not found")
+		respondError(w, r, http.StatusNotFound, "Hook not found")
 		return
 	}
 
@@ -323,7 +425,7 @@ func (h *Handlers) ReadLogFile(w http.ResponseWriter, r *http.Request) {
 	// VULN: No validation that path is within logs directory
 	content, err := os.ReadFile(logPath) // TAINT SINK
 	if err != nil {
-		respondError(w, http.StatusNotFound, "Log file not found")
+		respondError(w, r, http.StatusNotFound, "Log file not found")
 		return
 	}
 
@@ -337,7 +439,7 @@ func (h *Handlers) ReadLogFile(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) ImportConfig(w http.ResponseWriter, r *http.Request) {
 	file, header, err := r.FormFile("config")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "No file uploaded")
+		respondError(w, r, http.StatusBadRequest, "No file uploaded")
 		return
 	}
 	defer file.Close()
@@ -353,7 +455,7 @@ func (h *Handlers) ImportConfig(w http.ResponseWriter, r *http.Request) {
 
 	// VULN: Arbitrary file write
 	if err := os.WriteFile(savePath, content, 0644); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to save config")
+		respondError(w, r, http.StatusInternalServerError, "Failed to save config")
 		return
 	}
 
@@ -370,7 +472,7 @@ func (h *Handlers) SearchNotifications(w http.ResponseWriter, r *http.Request) {
 	// VULN: Search query used in SQL LIKE without escaping
 	results, err := h.store.Search(query)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+		respondError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -380,42 +482,127 @@ func (h *Handlers) SearchNotifications(w http.ResponseWriter, r *http.Request) {
 // ExportNotifications exports notifications to a file
 // TAINT FLOW: User specifies output format and path
 func (h *Handlers) ExportNotifications(w http.ResponseWriter, r *http.Request) {
-	format := r.URL.Query().Get("format") // csv, json, xml
+	format := r.URL.Query().Get("format") // csv, xml, ndjson, parquet
 	output := r.URL.Query().Get("output") // VULN: Arbitrary path
 
-	notifications, _ := h.store.ListNotifications("", "", "", "1000", "id")
+	rows, err := h.store.Notifications().List(r.Context(), notificationrepo.Filter{
+		OrderBy: notificationrepo.OrderByID,
+		Limit:   exporters.DefaultLimits.MaxRows,
+	})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "Failed to list notifications")
+		return
+	}
 
-	var content []byte
+	var enc exporters.Format
 	switch format {
-	case "csv":
-		content = utils.ToCSV(notifications)
 	case "xml":
-		content = utils.ToXML(notifications)
+		enc = exporters.XMLFormat{}
+	case "ndjson":
+		enc = exporters.NDJSONFormat{}
+	case "parquet":
+		enc = exporters.ParquetFormat{}
 	default:
-		content, _ = json.Marshal(notifications)
+		format = "csv"
+		enc = exporters.CSVFormat{SafeMode: true}
 	}
 
 	if output != "" {
 		// VULN: Arbitrary file write to user-specified path
-		os.WriteFile(output, content, 0644)
+		f, err := os.Create(output)
+		if err != nil {
+			respondError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to open output: %v", err))
+			return
+		}
+		defer f.Close()
+
+		if err := exporters.Export(f, enc, rows, exporters.DefaultLimits); err != nil {
+			respondError(w, r, http.StatusInternalServerError, fmt.Sprintf("Export failed: %v", err))
+			return
+		}
 		respondJSON(w, http.StatusOK, map[string]string{"exported": output})
-	} else {
-		w.Header().Set("Content-Type", "application/octet-stream")
-		w.Write(content)
+		return
+	}
+
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="notifications.%s"`, enc.Extension()))
+	if err := exporters.Export(w, enc, rows, exporters.DefaultLimits); err != nil {
+		log.Printf("export notifications: %v", err)
 	}
 }
 
-// ProcessCallback handles webhook callbacks
-// TAINT SOURCE: Entire request body and headers
+// ListDeadLetters returns every webhook notification that exhausted its
+// retry budget.
+func (h *Handlers) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	deadLetters, err := h.store.Deliveries().ListDeadLetters(r.Context())
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to list dead letters: %v", err))
+		return
+	}
+	respondJSON(w, http.StatusOK, deadLetters)
+}
+
+// ReplayDeadLetter re-dispatches a dead-lettered notification. On success
+// it's marked replayed; on failure it's left in the dead-letter table so
+// it can be retried again later.
+func (h *Handlers) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "Invalid dead letter id")
+		return
+	}
+
+	deadLetter, err := h.store.Deliveries().GetDeadLetter(r.Context(), id)
+	if err != nil {
+		respondError(w, r, http.StatusNotFound, "Dead letter not found")
+		return
+	}
+
+	notification := &channels.Notification{
+		ID:        deadLetter.NotificationID,
+		Channel:   deadLetter.Channel,
+		Recipient: deadLetter.Recipient,
+		Subject:   deadLetter.Subject,
+		Message:   deadLetter.Message,
+	}
+
+	result, err := h.dispatcher.Dispatch(notification)
+	if err != nil {
+		respondError(w, r, http.StatusBadGateway, fmt.Sprintf("Replay failed: %v", err))
+		return
+	}
+
+	if err := h.store.Deliveries().MarkReplayed(r.Context(), deadLetter.ID); err != nil {
+		respondError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to mark replayed: %v", err))
+		return
+	}
+	h.store.UpdateStatus(deadLetter.NotificationID, "sent", "")
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// ProcessCallback handles inbound signed webhook callbacks. callbackID's
+// signature is checked against h.callbackVerifiers before anything below
+// trusts the body or headers; an id with no configured verifier, or a
+// request that fails verification, is rejected with 401 without saying why.
+// Once verified, callbackID's registered callbacks.Handler (if any) runs -
+// there is no longer a way for the request itself to choose what runs, the
+// way the old X-Callback-Hook header did.
 func (h *Handlers) ProcessCallback(w http.ResponseWriter, r *http.Request) {
 	callbackID := mux.Vars(r)["id"]
 
 	// Read callback body
 	body, _ := io.ReadAll(r.Body)
 
-	// VULN: Callback data processed without validation
-	var callbackData map[string]interface{}
-	json.Unmarshal(body, &callbackData)
+	verifier, ok := h.callbackVerifiers[callbackID]
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if err := verifier.Verify(callbackID, r.Header, body); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
 
 	// Log callback with user-controlled data
 	logEntry := fmt.Sprintf("[CALLBACK %s] Headers: %v Body: %s\n",
@@ -430,18 +617,97 @@ func (h *Handlers) ProcessCallback(w http.ResponseWriter, r *http.Request) {
 	f.WriteString(logEntry)
 	f.Close()
 
-	// Execute callback hook if configured
-	if hookCmd := r.Header.Get("X-Callback-Hook"); hookCmd != "" {
-		// VULN: Header value used in command execution
-		go func() {
-			cmd := exec.Command("sh", "-c", hookCmd) // TAINT SINK: Command injection
-			cmd.Run()
-		}()
+	if handler, ok := h.callbackHandlers.Get(callbackID); ok {
+		if err := handler(callbackID, r.Header, body); err != nil {
+			respondError(w, r, http.StatusInternalServerError, "Callback handler failed: "+err.Error())
+			return
+		}
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"status": "processed"})
 }
 
+// RotateCallbackSecret sets callbackID's current signing secret, replacing
+// whatever was there before; the change takes effect on the next inbound
+// callback, since each Verifier's SecretsFunc reads the secret fresh from
+// storage every time.
+func (h *Handlers) RotateCallbackSecret(w http.ResponseWriter, r *http.Request) {
+	callbackID := mux.Vars(r)["id"]
+
+	var req struct {
+		Secret string `json:"secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if req.Secret == "" {
+		respondError(w, r, http.StatusBadRequest, "secret is required")
+		return
+	}
+
+	if err := h.store.CallbackSecrets().Rotate(r.Context(), callbackID, req.Secret); err != nil {
+		respondError(w, r, http.StatusInternalServerError, "Failed to rotate secret: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"callback_id": callbackID,
+		"rotated":     true,
+	})
+}
+
+// PauseJobType pauses every queued job of a given type so operators can
+// drain a misbehaving downstream without losing queued work.
+func (h *Handlers) PauseJobType(w http.ResponseWriter, r *http.Request) {
+	jobType := mux.Vars(r)["type"]
+
+	count, err := h.worker.PauseQueue(jobType)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "Failed to pause jobs: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"type":   jobType,
+		"paused": count,
+	})
+}
+
+// ResumeJobType resumes every paused job of a given type without resetting
+// retry counters.
+func (h *Handlers) ResumeJobType(w http.ResponseWriter, r *http.Request) {
+	jobType := mux.Vars(r)["type"]
+
+	count, err := h.worker.ResumeQueue(jobType)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "Failed to resume jobs: "+err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"type":    jobType,
+		"resumed": count,
+	})
+}
+
+// ReloadTemplate evicts a template's compiled cache entry so the next
+// Render picks up whatever is currently on disk. Templates edited on a
+// mounted templatesDir are already picked up automatically when the
+// server is started with a WatchingRenderer; this endpoint covers the
+// same case for deployments that aren't, or an operator who doesn't want
+// to wait for the fsnotify event to land.
+func (h *Handlers) ReloadTemplate(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	h.renderer.Reload(name)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"template": name,
+		"reloaded": true,
+	})
+}
+
 // Utility functions
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -449,8 +715,11 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
+// respondError writes an application/problem+json error body carrying
+// r's correlation ID (see api.RequestIDMiddleware), so a client-reported
+// error can be matched to the server-side log entry that recorded it.
+func respondError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	httpapi.WriteProblem(w, status, message, RequestIDFromContext(r.Context()))
 }
 
 // DebugRequest logs full request details - useful for debugging
@@ -471,19 +740,21 @@ func (h *Handlers) DebugRequest(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, debug)
 }
 
-// ProxyRequest proxies a request to another service
-// VULN: Open redirect / SSRF
+// ProxyRequest proxies a request to another service. The target is still
+// whatever the caller passes in, but h.proxyClient (a safehttp.Client)
+// rejects internal/loopback/link-local destinations before connecting and
+// on every redirect hop, so this can no longer be used to reach the
+// cloud metadata endpoint, Redis, or other internal services.
 func (h *Handlers) ProxyRequest(w http.ResponseWriter, r *http.Request) {
 	targetURL := r.URL.Query().Get("target")
 
-	// VULN: No URL validation - SSRF
 	if !strings.HasPrefix(targetURL, "http") {
 		targetURL = "http://" + targetURL
 	}
 
-	resp, err := http.Get(targetURL) // TAINT SINK: User-controlled URL
+	resp, err := h.proxyClient.Get(targetURL)
 	if err != nil {
-		respondError(w, http.StatusBadGateway, err.Error())
+		respondError(w, r, http.StatusBadGateway, err.Error())
 		return
 	}
 	defer resp.Body.Close()
@@ -2,47 +2,57 @@
 package api
 
 import (
+	"context"
+	cryptorand "crypto/rand"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
+	"net/url"
+	"regexp"
 	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/auditlog"
+	"github.com/project-anarchy/go_notifications/internal/auth"
+	"github.com/project-anarchy/go_notifications/internal/httpapi"
 )
 
-// LoggingMiddleware logs all incoming requests
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// VULN: Log injection via User-Agent or other headers
-		// Newlines in header values can create fake log entries
-		log.Printf("[%s] %s %s - %s - %s",
-			r.Method,
-			r.URL.Path,
-			r.RemoteAddr,
-			r.UserAgent(), // TAINT: User-controlled
-			r.Header.Get("X-Request-ID"), // TAINT: User-controlled
-		)
-
-		// Create response wrapper to capture status
-		wrapped := &responseWrapper{ResponseWriter: w, status: 200}
-		next.ServeHTTP(wrapped, r)
-
-		// Log completion
-		log.Printf("[%s] %s completed in %v with status %d",
-			r.Method,
-			r.URL.Path,
-			time.Since(start),
-			wrapped.status,
-		)
-	})
+// LoggingMiddleware logs one structured JSON line per request to logger,
+// replacing the old log.Printf calls that interpolated User-Agent and
+// X-Request-ID directly - a newline in either could forge extra log
+// entries. User-Agent now goes through auditlog.Sanitize, which escapes
+// CR/LF, and the request ID is the one RequestIDMiddleware already
+// validated or generated.
+func LoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			wrapped := &responseWrapper{ResponseWriter: w, status: 200}
+			next.ServeHTTP(wrapped, r)
+
+			logger.Info("request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", wrapped.status,
+				"duration_ms", time.Since(start).Milliseconds(),
+				"client_ip", r.RemoteAddr,
+				"request_id", RequestIDFromContext(r.Context()),
+				"user_agent", auditlog.Sanitize(r.UserAgent()),
+				"response_bytes", wrapped.bytes,
+			)
+		})
+	}
 }
 
 type responseWrapper struct {
 	http.ResponseWriter
 	status int
+	bytes  int
 }
 
 func (rw *responseWrapper) WriteHeader(code int) {
@@ -50,52 +60,81 @@ func (rw *responseWrapper) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// CORSMiddleware handles Cross-Origin Resource Sharing
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-
-		// VULN: Reflects any origin - no validation
-		if origin != "" {
-			w.Header().Set("Access-Control-Allow-Origin", origin) // TAINT SINK: Reflected header
-		} else {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-		}
+func (rw *responseWrapper) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
 
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID")
-		w.Header().Set("Access-Control-Allow-Credentials", "true") // VULN: With reflected origin
+// CORSConfig configures CORSMiddleware.
+type CORSConfig struct {
+	// AllowedOrigins lists origins CORSMiddleware echoes back in
+	// Access-Control-Allow-Origin. An entry may be an exact origin
+	// ("https://app.example.com") or a wildcard suffix
+	// ("*.example.com", matching any subdomain but not the bare
+	// domain). An unmatched Origin gets no CORS headers at all, rather
+	// than the old behavior of reflecting whatever the browser sent.
+	AllowedOrigins []string
+	// AllowedMethods is the Access-Control-Allow-Methods value.
+	AllowedMethods []string
+	// AllowedHeaders is the Access-Control-Allow-Headers value.
+	AllowedHeaders []string
+	// ExposedHeaders is the Access-Control-Expose-Headers value.
+	ExposedHeaders []string
+	// AllowCredentials sets Access-Control-Allow-Credentials: true for a
+	// matched origin. Ignored (never set) if AllowedOrigins contains the
+	// literal "*", since the CORS spec forbids combining a wildcard
+	// origin with credentials.
+	AllowCredentials bool
+	// MaxAge sets Access-Control-Max-Age, in seconds, on preflight
+	// (OPTIONS) responses.
+	MaxAge time.Duration
+}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// CORSMiddleware handles Cross-Origin Resource Sharing according to cfg,
+// replacing the old CORSMiddleware's reflect-any-origin-and-always-allow-
+// credentials behavior. An Origin that doesn't match cfg.AllowedOrigins
+// gets no CORS headers, so the browser itself blocks the cross-origin
+// read.
+func CORSMiddleware(cfg CORSConfig) func(http.Handler) http.Handler {
+	wildcardOrigin := false
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			wildcardOrigin = true
+			break
 		}
+	}
 
-		next.ServeHTTP(w, r)
-	})
-}
-
-// APIKeyMiddleware validates API key authentication
-func APIKeyMiddleware(validKey string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Check multiple sources for API key
-			apiKey := r.Header.Get("X-API-Key")
-			if apiKey == "" {
-				apiKey = r.URL.Query().Get("api_key") // VULN: API key in URL (logged, cached)
-			}
-			if apiKey == "" {
-				apiKey = r.Header.Get("Authorization")
-				if strings.HasPrefix(apiKey, "Bearer ") {
-					apiKey = strings.TrimPrefix(apiKey, "Bearer ")
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin != "" && originAllowed(origin, cfg.AllowedOrigins) {
+				if wildcardOrigin {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					if cfg.AllowCredentials {
+						w.Header().Set("Access-Control-Allow-Credentials", "true")
+					}
+				}
+				if len(cfg.AllowedMethods) > 0 {
+					w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+				}
+				if len(cfg.AllowedHeaders) > 0 {
+					w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+				}
+				if len(cfg.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+				}
+				if r.Method == "OPTIONS" && cfg.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", fmt.Sprintf("%d", int(cfg.MaxAge.Seconds())))
 				}
 			}
 
-			// VULN: Timing attack - string comparison not constant-time
-			if apiKey != validKey {
-				// VULN: Logs attempted API key
-				log.Printf("Invalid API key attempt: %s from %s", apiKey, r.RemoteAddr)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
 				return
 			}
 
@@ -104,51 +143,97 @@ func APIKeyMiddleware(validKey string) func(http.Handler) http.Handler {
 	}
 }
 
-// RecoveryMiddleware recovers from panics
-func RecoveryMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				// VULN: Stack trace exposed to client
-				stackTrace := string(debug.Stack())
-				log.Printf("Panic recovered: %v\n%s", err, stackTrace)
-
-				// VULN: Internal error details exposed
-				http.Error(w, fmt.Sprintf("Internal error: %v\nStack: %s", err, stackTrace), http.StatusInternalServerError)
+// originAllowed reports whether origin matches one of allowed's entries,
+// each either an exact origin or a "*.example.com" wildcard suffix
+// matching any subdomain of example.com (but not example.com itself).
+func originAllowed(origin string, allowed []string) bool {
+	host := origin
+	if u, err := url.Parse(origin); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	for _, pattern := range allowed {
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
 			}
-		}()
-		next.ServeHTTP(w, r)
-	})
+		}
+	}
+	return false
 }
 
-// RateLimitMiddleware implements basic rate limiting
-// VULN: Easily bypassed with X-Forwarded-For header spoofing
-func RateLimitMiddleware(requestsPerMinute int) func(http.Handler) http.Handler {
-	// Simple in-memory store (not production-ready)
-	requestCounts := make(map[string]int)
-	lastReset := time.Now()
-
+// AllowedHostsMiddleware rejects any request whose Host header isn't in
+// allowedHosts (SecurityConfig.AllowedHosts), with a 403. An empty
+// allowedHosts allows every host, preserving that default for operators
+// who haven't configured it.
+func AllowedHostsMiddleware(allowedHosts []string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Reset counts every minute
-			if time.Since(lastReset) > time.Minute {
-				requestCounts = make(map[string]int)
-				lastReset = time.Now()
+			if len(allowedHosts) == 0 {
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			// VULN: Uses X-Forwarded-For which can be spoofed
-			clientIP := r.Header.Get("X-Forwarded-For")
-			if clientIP == "" {
-				clientIP = r.Header.Get("X-Real-IP")
+			host := hostOf(r.Host)
+			for _, allowed := range allowedHosts {
+				if strings.EqualFold(host, allowed) {
+					next.ServeHTTP(w, r)
+					return
+				}
 			}
-			if clientIP == "" {
-				clientIP = r.RemoteAddr
+
+			http.Error(w, "Forbidden host", http.StatusForbidden)
+		})
+	}
+}
+
+// identityContextKey is the request context key AuthMiddleware stores the
+// authenticated *auth.Identity under, for RequireRole (or a handler) to
+// read back out.
+type identityContextKey struct{}
+
+// AuthMiddleware authenticates every request via authenticator - an
+// auth.Chain built from Config.Auth.Routes by cmd/server/main.go, trying
+// each configured Authenticator (API key, HTTP Basic, OIDC) in order -
+// replacing the old APIKeyMiddleware's single hardcoded key compared with
+// !=. On success, the resolved *auth.Identity is attached to the request
+// context so RequireRole (and handlers, via IdentityFromContext) can read
+// it back out.
+func AuthMiddleware(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := authenticator.Authenticate(r)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
 			}
 
-			// Increment and check
-			requestCounts[clientIP]++
-			if requestCounts[clientIP] > requestsPerMinute {
-				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			ctx := context.WithValue(r.Context(), identityContextKey{}, identity)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// IdentityFromContext returns the *auth.Identity AuthMiddleware attached
+// to ctx, or nil if none is present (AuthMiddleware didn't run).
+func IdentityFromContext(ctx context.Context) *auth.Identity {
+	identity, _ := ctx.Value(identityContextKey{}).(*auth.Identity)
+	return identity
+}
+
+// RequireRole restricts access to identities holding role, replacing
+// AdminOnlyMiddleware's X-Admin: true header check and hardcoded
+// ?admin=supersecret backdoor. It must run after AuthMiddleware, which is
+// what actually populates the identity RequireRole checks.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity := IdentityFromContext(r.Context())
+			if identity == nil || !identity.HasRole(role) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
 
@@ -157,62 +242,266 @@ func RateLimitMiddleware(requestsPerMinute int) func(http.Handler) http.Handler
 	}
 }
 
-// AuditMiddleware logs detailed audit trail
-func AuditMiddleware(next http.Handler) http.Handler {
-	// Open audit log file
-	auditFile, err := os.OpenFile("./logs/audit.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// RecoveryMiddleware recovers from panics, logging the panic value and
+// stack trace to logger (server-side only) and responding with an
+// application/problem+json body carrying the request's correlation ID
+// (see RequestIDMiddleware/RequestIDFromContext) - replacing the old
+// behavior of writing the panic value and full stack trace straight into
+// the HTTP response.
+func RecoveryMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					corrID := RequestIDFromContext(r.Context())
+					logger.Error("panic recovered",
+						"error", fmt.Sprintf("%v", err),
+						"stack", string(debug.Stack()),
+						"request_id", corrID,
+						"method", r.Method,
+						"path", r.URL.Path,
+					)
+					httpapi.WriteProblem(w, http.StatusInternalServerError, "an internal error occurred", corrID)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RealClientIP resolves r's real client IP, accounting for trusted
+// reverse proxies: if the immediate peer (r.RemoteAddr) doesn't itself
+// fall inside one of trusted's CIDRs, X-Forwarded-For/X-Real-IP are
+// ignored entirely - an untrusted peer can't just hand us whatever IP it
+// likes via a header. Otherwise, the X-Forwarded-For chain (left to
+// right: client, proxy1, proxy2, ...) is walked from the right, skipping
+// any hop that itself falls inside trusted, and the first untrusted hop
+// found is returned - the first entry a client couldn't have forged by
+// prepending fake addresses, since each trusted proxy only appends the
+// peer it actually observed.
+func RealClientIP(r *http.Request, trusted []string) string {
+	remoteIP := hostOf(r.RemoteAddr)
+	if remoteIP == "" || !isTrustedProxy(remoteIP, trusted) {
+		if remoteIP != "" {
+			return remoteIP
+		}
+		return r.RemoteAddr
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" || net.ParseIP(hop) == nil || isTrustedProxy(hop, trusted) {
+				continue
+			}
+			return hop
+		}
+	}
+
+	if xrip := strings.TrimSpace(r.Header.Get("X-Real-IP")); xrip != "" && net.ParseIP(xrip) != nil {
+		return xrip
+	}
+
+	return remoteIP
+}
+
+// isTrustedProxy reports whether ip falls inside one of trusted's CIDRs,
+// mirroring webhook.go's validateHostAllowList.
+func isTrustedProxy(ip string, trusted []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, cidr := range trusted {
+		if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOf strips RemoteAddr's port, if present, falling back to the raw
+// value for inputs (e.g. in tests) that are already a bare IP.
+func hostOf(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
 	if err != nil {
-		log.Printf("Warning: Could not open audit log: %v", err)
-		return next
+		return remoteAddr
 	}
+	return host
+}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// VULN: Logs sensitive data including auth headers and body
-		auditEntry := fmt.Sprintf("[%s] %s %s %s | Auth: %s | User-Agent: %s | Body-Size: %d\n",
-			time.Now().Format(time.RFC3339),
-			r.Method,
-			r.URL.String(), // TAINT: Full URL including query params with secrets
-			r.RemoteAddr,
-			r.Header.Get("Authorization"), // VULN: Logs auth token
-			r.UserAgent(),
-			r.ContentLength,
-		)
-
-		auditFile.WriteString(auditEntry)
-
-		next.ServeHTTP(w, r)
+// ipBucket is one client IP's token bucket, refilling at the enclosing
+// ipRateLimiter's configured rate up to its burst. seen records the last
+// Allow call's time so ipRateLimiter.maybeGC can evict idle buckets.
+type ipBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	seen   int64
+}
+
+// ipRateLimiter is a token-bucket limiter keyed by resolved client IP,
+// replacing RateLimitMiddleware's old map+reset-timer counter (which
+// reset every client's count in lockstep every minute, and keyed
+// directly on the spoofable X-Forwarded-For value). Idle buckets are
+// swept on an occasional Allow call rather than a background goroutine,
+// the same check-on-request style the old implementation used for its
+// own per-minute reset.
+type ipRateLimiter struct {
+	buckets    sync.Map // string -> *ipBucket
+	ratePerMin float64
+	burst      float64
+	gcInterval time.Duration
+	lastGC     int64
+}
+
+func newIPRateLimiter(requestsPerMinute, burst int) *ipRateLimiter {
+	if burst <= 0 {
+		burst = requestsPerMinute
+	}
+	return &ipRateLimiter{
+		ratePerMin: float64(requestsPerMinute),
+		burst:      float64(burst),
+		gcInterval: 5 * time.Minute,
+	}
+}
+
+func (l *ipRateLimiter) allow(ip string) bool {
+	now := time.Now()
+	l.maybeGC(now)
+
+	v, _ := l.buckets.LoadOrStore(ip, &ipBucket{tokens: l.burst, last: now})
+	b := v.(*ipBucket)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += now.Sub(b.last).Minutes() * l.ratePerMin
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	atomic.StoreInt64(&b.seen, now.Unix())
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// maybeGC evicts buckets idle for longer than gcInterval, at most once
+// per gcInterval (the CompareAndSwap makes concurrent callers race for
+// who actually runs the sweep harmlessly).
+func (l *ipRateLimiter) maybeGC(now time.Time) {
+	last := atomic.LoadInt64(&l.lastGC)
+	if now.Unix()-last < int64(l.gcInterval.Seconds()) {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&l.lastGC, last, now.Unix()) {
+		return
+	}
+	cutoff := now.Add(-l.gcInterval).Unix()
+	l.buckets.Range(func(key, value interface{}) bool {
+		if atomic.LoadInt64(&value.(*ipBucket).seen) < cutoff {
+			l.buckets.Delete(key)
+		}
+		return true
 	})
 }
 
-// AdminOnlyMiddleware restricts access to admin endpoints
-// VULN: Header-based auth bypass
-func AdminOnlyMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// VULN: Trusts client-provided header
-		if r.Header.Get("X-Admin") != "true" {
-			// Also check for backdoor
-			if r.URL.Query().Get("admin") != "supersecret" { // VULN: Hardcoded backdoor
-				http.Error(w, "Admin access required", http.StatusForbidden)
+// RateLimit implements per-client-IP rate limiting: requestsPerMinute
+// tokens refill continuously, up to burst requests allowed in a single
+// burst (burst <= 0 defaults to requestsPerMinute). The client IP is
+// resolved via RealClientIP(r, trustedProxies), so a request can no
+// longer reset or dodge its bucket by sending a spoofed
+// X-Forwarded-For/X-Real-IP value through an untrusted hop.
+func RateLimit(requestsPerMinute, burst int, trustedProxies []string) func(http.Handler) http.Handler {
+	limiter := newIPRateLimiter(requestsPerMinute, burst)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := RealClientIP(r, trustedProxies)
+			if !limiter.allow(ip) {
+				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
 				return
 			}
-		}
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-// RequestIDMiddleware adds request ID for tracing
+// AuditMiddleware logs a structured audit trail entry per request to
+// logger, gated by SecurityConfig.EnableAuditLog in NewRouter. Unlike
+// LoggingMiddleware it also records the request URL (including query
+// string) and Authorization header for security review, so it replaces
+// the old hand-rolled os.OpenFile("./logs/audit.log") call and the raw
+// Authorization/URL values that call used to write verbatim: both now go
+// through auditlog.RedactURL/RedactHeaderValue first.
+func AuditMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.Info("audit",
+				"method", r.Method,
+				"url", auditlog.RedactURL(r.URL),
+				"client_ip", r.RemoteAddr,
+				"request_id", RequestIDFromContext(r.Context()),
+				"authorization", auditlog.RedactHeaderValue("Authorization", r.Header.Get("Authorization")),
+				"user_agent", auditlog.Sanitize(r.UserAgent()),
+				"content_length", r.ContentLength,
+			)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestIDContextKey is the request context key RequestIDMiddleware
+// stores the resolved correlation ID under.
+type requestIDContextKey struct{}
+
+// requestIDPattern bounds an incoming X-Request-ID to a safe shape before
+// it's ever reflected into a response header or a log line - a client
+// value that doesn't match gets replaced with a generated one instead.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// RequestIDMiddleware assigns each request a correlation ID: a
+// client-supplied X-Request-ID is reused only if it matches
+// requestIDPattern, otherwise a random UUIDv4 is generated - replacing
+// the old fallback to time.Now().UnixNano() and reflecting an
+// unvalidated client value verbatim. The resolved ID is set on the
+// response's X-Request-ID header and attached to the request context (see
+// RequestIDFromContext) so LoggingMiddleware, AuditMiddleware, and
+// RecoveryMiddleware's problem+json body all report the same ID.
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := r.Header.Get("X-Request-ID")
-		if requestID == "" {
-			// Generate simple ID (not cryptographically secure)
-			requestID = fmt.Sprintf("%d", time.Now().UnixNano())
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = newRequestID()
 		}
 
-		// VULN: Reflects user-controlled header back
 		w.Header().Set("X-Request-ID", requestID)
-
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), requestIDContextKey{}, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequestIDFromContext returns the correlation ID RequestIDMiddleware
+// attached to ctx, or "" if it didn't run.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// newRequestID generates a random UUIDv4 using crypto/rand.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -2,38 +2,78 @@
 package api
 
 import (
+	"log/slog"
+	"net/http"
+
 	"github.com/gorilla/mux"
+
+	"github.com/project-anarchy/go_notifications/internal/auth"
 )
 
-// NewRouter creates and configures the HTTP router
-func NewRouter(h *Handlers, apiKey string) *mux.Router {
+// NewRouter creates and configures the HTTP router. rpcServer is optional;
+// pass nil to omit the /rpc endpoint entirely (cfg.RPC.Enabled == false).
+// requestLogger backs LoggingMiddleware; auditLogger backs AuditMiddleware,
+// which is only installed when enableAuditLog is true
+// (cfg.Security.EnableAuditLog). RateLimit is only installed when
+// rateLimitPerMin > 0 (cfg.Security.RateLimitPerMin), and is configured
+// with rateLimitBurst and trustedProxies (cfg.Security.RateLimitBurst /
+// TrustedProxies). apiAuth authenticates every /api route (built by
+// cmd/server/main.go via auth.BuildChain from Config.Auth.Routes["api"]);
+// adminRole additionally gates /api/admin/* routes via RequireRole, once
+// apiAuth has populated the request's identity. corsCfg configures
+// CORSMiddleware (cfg.Security.CORS); allowedHosts configures
+// AllowedHostsMiddleware (cfg.Security.AllowedHosts).
+func NewRouter(h *Handlers, apiAuth auth.Authenticator, adminRole string, rpcServer http.Handler, requestLogger, auditLogger *slog.Logger, enableAuditLog bool, rateLimitPerMin, rateLimitBurst int, trustedProxies []string, corsCfg CORSConfig, allowedHosts []string) *mux.Router {
 	r := mux.NewRouter()
 
-	// Apply global middleware
-	r.Use(LoggingMiddleware)
-	r.Use(CORSMiddleware)
-	r.Use(RecoveryMiddleware)
+	// Apply global middleware. RequestIDMiddleware runs first so every
+	// later middleware can read the resolved correlation ID back out of
+	// the request context (see RequestIDFromContext).
+	r.Use(RequestIDMiddleware)
+	r.Use(LoggingMiddleware(requestLogger))
+	r.Use(AllowedHostsMiddleware(allowedHosts))
+	r.Use(CORSMiddleware(corsCfg))
+	r.Use(RecoveryMiddleware(requestLogger))
+	if enableAuditLog {
+		r.Use(AuditMiddleware(auditLogger))
+	}
+	if rateLimitPerMin > 0 {
+		r.Use(RateLimit(rateLimitPerMin, rateLimitBurst, trustedProxies))
+	}
 
 	// Public endpoints (no auth required)
 	r.HandleFunc("/api/health", h.HealthCheck).Methods("GET")
 	r.HandleFunc("/api/debug", h.DebugRequest).Methods("GET", "POST")
 
-	// Callback endpoint (signature verified separately)
+	// Remote worker protocol - rpc.Server authenticates the connection
+	// itself against its own shared secret, independent of apiAuth.
+	if rpcServer != nil {
+		r.Handle("/rpc", rpcServer)
+	}
+
+	// Callback endpoint - ProcessCallback verifies the inbound signature
+	// itself, via h.callbackVerifiers, before trusting the body.
 	r.HandleFunc("/api/callback/{id}", h.ProcessCallback).Methods("POST")
 
 	// Protected API routes
 	api := r.PathPrefix("/api").Subrouter()
-	api.Use(APIKeyMiddleware(apiKey))
+	api.Use(AuthMiddleware(apiAuth))
 
 	// Notification endpoints
 	api.HandleFunc("/notify", h.SendNotification).Methods("POST")
 	api.HandleFunc("/notify/batch", h.SendBatchNotification).Methods("POST")
 	api.HandleFunc("/notify/template", h.SendTemplatedNotification).Methods("POST")
 
+	// Template management
+	api.HandleFunc("/templates", h.ListTemplates).Methods("GET")
+	api.HandleFunc("/templates/{name}/preview", h.PreviewTemplate).Methods("POST")
+
 	// Query endpoints
 	api.HandleFunc("/notifications", h.ListNotifications).Methods("GET")
 	api.HandleFunc("/notifications/search", h.SearchNotifications).Methods("GET")
 	api.HandleFunc("/notifications/export", h.ExportNotifications).Methods("GET")
+	api.HandleFunc("/notifications/dead-letter", h.ListDeadLetters).Methods("GET")
+	api.HandleFunc("/notifications/dead-letter/{id}/replay", h.ReplayDeadLetter).Methods("POST")
 
 	// Webhook testing
 	api.HandleFunc("/webhook/test", h.TestWebhook).Methods("POST")
@@ -50,5 +90,21 @@ func NewRouter(h *Handlers, apiKey string) *mux.Router {
 	// Proxy endpoint
 	api.HandleFunc("/proxy", h.ProxyRequest).Methods("GET", "POST")
 
+	// Admin routes - require adminRole in addition to apiAuth, replacing
+	// the old AdminOnlyMiddleware's X-Admin header / ?admin=supersecret
+	// backdoor.
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(RequireRole(adminRole))
+
+	// Admin job queue control
+	admin.HandleFunc("/jobs/{type}/pause", h.PauseJobType).Methods("POST")
+	admin.HandleFunc("/jobs/{type}/resume", h.ResumeJobType).Methods("POST")
+
+	// Admin template cache control
+	admin.HandleFunc("/templates/{name}/reload", h.ReloadTemplate).Methods("POST")
+
+	// Callback secret rotation
+	admin.HandleFunc("/callbacks/{id}/secret", h.RotateCallbackSecret).Methods("POST")
+
 	return r
 }
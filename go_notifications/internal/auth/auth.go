@@ -0,0 +1,60 @@
+// Package auth provides pluggable request authentication: an
+// Authenticator interface with API key, HTTP Basic (htpasswd-backed), and
+// OIDC/JWT bearer implementations, composed into an ordered Chain and
+// wired into the HTTP layer by api.AuthMiddleware/api.RequireRole.
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrUnauthenticated is returned by an Authenticator when the request
+// carries no credential it recognizes, or the credential it found doesn't
+// verify - the two are deliberately indistinguishable to a caller so an
+// HTTP handler can't leak which case applies.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Identity is the authenticated principal an Authenticator resolves a
+// request to.
+type Identity struct {
+	// Subject identifies the principal (a key ID, a username, or a JWT
+	// "sub" claim) for logging and audit purposes.
+	Subject string
+	// Roles are the role claims this principal holds, checked by
+	// api.RequireRole (e.g. "admin").
+	Roles []string
+}
+
+// HasRole reports whether id holds role.
+func (id *Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator resolves r to an Identity, or returns ErrUnauthenticated
+// if it can't.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Chain tries each Authenticator in order, returning the first successful
+// Identity - the pluggable "per-route auth chain" Config.Auth.Routes
+// assembles by name (see BuildChain).
+type Chain []Authenticator
+
+// Authenticate returns the first Identity any authenticator in c resolves
+// r to, or ErrUnauthenticated if none do.
+func (c Chain) Authenticate(r *http.Request) (*Identity, error) {
+	for _, a := range c {
+		id, err := a.Authenticate(r)
+		if err == nil {
+			return id, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
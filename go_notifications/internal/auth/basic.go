@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuthenticator authenticates HTTP Basic credentials against an
+// htpasswd file (bcrypt-hashed entries only, "user:$2y$..." per line -
+// the format abbot/go-http-auth's HtpasswdFileProvider reads), reloading
+// it when its mtime changes rather than caching it for the process
+// lifetime.
+type BasicAuthenticator struct {
+	path string
+	// roleFunc, if set, maps an authenticated username to its roles;
+	// nil means every user gets no roles (RequireRole then always
+	// rejects them, so an operator relying on roles must set this).
+	roleFunc func(username string) []string
+
+	mu      sync.Mutex
+	modTime int64
+	users   map[string]string // username -> bcrypt hash
+}
+
+// NewBasicAuthenticator creates a BasicAuthenticator reading htpasswd
+// entries from path. roleFunc may be nil.
+func NewBasicAuthenticator(path string, roleFunc func(username string) []string) *BasicAuthenticator {
+	return &BasicAuthenticator{path: path, roleFunc: roleFunc}
+}
+
+// Authenticate checks r's Basic credentials (RFC 7617) against the
+// htpasswd file, reloading it first if it's changed on disk.
+func (b *BasicAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	users, err := b.load()
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	hash, ok := users[username]
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	var roles []string
+	if b.roleFunc != nil {
+		roles = b.roleFunc(username)
+	}
+	return &Identity{Subject: username, Roles: roles}, nil
+}
+
+// load returns the cached username->hash map, reparsing b.path if its
+// mtime has changed since the last load.
+func (b *BasicAuthenticator) load() (map[string]string, error) {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.users != nil && info.ModTime().UnixNano() == b.modTime {
+		return b.users, nil
+	}
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		users[parts[0]] = parts[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("auth: htpasswd file %q has no entries", b.path)
+	}
+
+	b.users = users
+	b.modTime = info.ModTime().UnixNano()
+	return users, nil
+}
@@ -0,0 +1,20 @@
+package auth
+
+import "fmt"
+
+// BuildChain resolves names (e.g. ["apikey", "oidc"]) against registry -
+// built once at startup, keyed by the same names Config.Auth.Routes uses -
+// into a Chain tried in that order. An unknown name is an error rather
+// than silently skipped, so a typo in Config.Auth.Routes fails startup
+// instead of quietly authenticating nothing.
+func BuildChain(names []string, registry map[string]Authenticator) (Chain, error) {
+	chain := make(Chain, 0, len(names))
+	for _, name := range names {
+		a, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("auth: unknown authenticator %q", name)
+		}
+		chain = append(chain, a)
+	}
+	return chain, nil
+}
@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/pkg/safehttp"
+)
+
+// OIDCConfig configures an OIDCAuthenticator.
+type OIDCConfig struct {
+	// Issuer is the expected "iss" claim.
+	Issuer string
+	// JWKSURL is the issuer's JSON Web Key Set endpoint, fetched through
+	// a safehttp.Client so a misconfigured or compromised JWKSURL can't
+	// be pointed at an internal address.
+	JWKSURL string
+	// Audience, if set, is the expected "aud" claim.
+	Audience string
+	// RoleClaim names the claim holding the token's roles. Defaults to
+	// "roles".
+	RoleClaim string
+	// CacheTTL bounds how long a fetched JWKS is cached before
+	// OIDCAuthenticator re-fetches it. Defaults to 10 minutes.
+	CacheTTL time.Duration
+}
+
+// OIDCAuthenticator authenticates Bearer JWTs (RS256 only) against
+// Config.Issuer's JWKS, caching the key set for Config.CacheTTL rather
+// than fetching it on every request.
+type OIDCAuthenticator struct {
+	cfg    OIDCConfig
+	client *safehttp.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewOIDCAuthenticator creates an OIDCAuthenticator for cfg.
+func NewOIDCAuthenticator(cfg OIDCConfig) *OIDCAuthenticator {
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "roles"
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 10 * time.Minute
+	}
+	return &OIDCAuthenticator{
+		cfg:    cfg,
+		client: safehttp.NewClient(safehttp.DefaultPolicy("oidc-jwks")),
+	}
+}
+
+// Authenticate verifies r's Authorization: Bearer JWT against o.cfg.
+func (o *OIDCAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	authz := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authz, "Bearer ") {
+		return nil, ErrUnauthenticated
+	}
+
+	claims, err := o.verify(strings.TrimPrefix(authz, "Bearer "))
+	if err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	subject, _ := claims["sub"].(string)
+	return &Identity{Subject: subject, Roles: rolesFromClaim(claims[o.cfg.RoleClaim])}, nil
+}
+
+// verify checks token's signature against the issuer's JWKS and its
+// standard exp/nbf/iss/aud claims, returning the decoded claim set.
+func (o *OIDCAuthenticator) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("auth: malformed JWT")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("auth: unsupported JWT alg %q", header.Alg)
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payloadJSON, &claims); err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := o.keyFor(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, err
+	}
+
+	if err := validateClaims(claims, o.cfg); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func validateClaims(claims map[string]interface{}, cfg OIDCConfig) error {
+	now := time.Now().Unix()
+
+	if exp, ok := claims["exp"].(float64); ok && int64(exp) < now {
+		return fmt.Errorf("auth: token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && int64(nbf) > now {
+		return fmt.Errorf("auth: token not yet valid")
+	}
+	if cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != cfg.Issuer {
+			return fmt.Errorf("auth: unexpected issuer %q", iss)
+		}
+	}
+	if cfg.Audience != "" && !audienceContains(claims["aud"], cfg.Audience) {
+		return fmt.Errorf("auth: audience mismatch")
+	}
+	return nil
+}
+
+// audienceContains reports whether aud (a string or []interface{} of
+// strings, per the JWT spec) contains want.
+func audienceContains(aud interface{}, want string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == want
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rolesFromClaim coerces a decoded role claim (typically []interface{} of
+// strings) into []string; any other shape yields no roles rather than an
+// error, since a missing/malformed role claim should deny RequireRole
+// checks, not fail authentication outright.
+func rolesFromClaim(claim interface{}) []string {
+	list, ok := claim.([]interface{})
+	if !ok {
+		return nil
+	}
+	roles := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			roles = append(roles, s)
+		}
+	}
+	return roles
+}
+
+// keyFor returns kid's RSA public key, fetching (and caching for
+// o.cfg.CacheTTL) o.cfg.JWKSURL's key set if it isn't already cached. A
+// refetch failure falls back to a still-cached key set rather than
+// locking every request out because the issuer is briefly unreachable.
+func (o *OIDCAuthenticator) keyFor(kid string) (*rsa.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.keys == nil || time.Since(o.fetchedAt) > o.cfg.CacheTTL {
+		keys, err := o.fetchJWKS()
+		if err != nil && o.keys == nil {
+			return nil, err
+		}
+		if err == nil {
+			o.keys = keys
+			o.fetchedAt = time.Now()
+		}
+	}
+
+	key, ok := o.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("auth: unknown JWKS key id %q", kid)
+	}
+	return key, nil
+}
+
+type jwksResponse struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func (o *OIDCAuthenticator) fetchJWKS() (map[string]*rsa.PublicKey, error) {
+	resp, err := o.client.Get(o.cfg.JWKSURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var parsed jwksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(parsed.Keys))
+	for _, k := range parsed.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(nBytes),
+			E: int(new(big.Int).SetBytes(eBytes).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("auth: JWKS at %q has no usable RSA keys", o.cfg.JWKSURL)
+	}
+	return keys, nil
+}
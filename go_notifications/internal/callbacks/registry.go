@@ -0,0 +1,35 @@
+package callbacks
+
+import "net/http"
+
+// Handler processes a callback body once ProcessCallback has verified its
+// signature. id is the route's {id}, already known to the handler that was
+// looked up under it, but passed through in case one Handler is registered
+// under more than one id.
+type Handler func(id string, header http.Header, body []byte) error
+
+// Registry maps callback IDs to the Handler that should run once
+// ProcessCallback verifies that ID's signature, replacing the old
+// X-Callback-Hook header (which ran an arbitrary shell command supplied by
+// the caller) with a fixed set of handlers this service's own code
+// registers at startup.
+type Registry struct {
+	handlers map[string]Handler
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Handler)}
+}
+
+// Register adds handler under id, replacing any previous registration for
+// that id.
+func (r *Registry) Register(id string, handler Handler) {
+	r.handlers[id] = handler
+}
+
+// Get returns the handler registered under id, if any.
+func (r *Registry) Get(id string) (Handler, bool) {
+	h, ok := r.handlers[id]
+	return h, ok
+}
@@ -0,0 +1,38 @@
+package callbacks
+
+import "sync"
+
+// seenCache is a bounded FIFO set of (id, timestamp, signature) tuples
+// Verify has already accepted, so a captured request can't be replayed a
+// second time within the freshness window. Capacity bounds memory under
+// sustained traffic; once full, the oldest entry is evicted to make room.
+type seenCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    []string
+	index    map[string]struct{}
+}
+
+func newSeenCache(capacity int) *seenCache {
+	return &seenCache{capacity: capacity, index: make(map[string]struct{}, capacity)}
+}
+
+// checkAndAdd reports whether key has been seen before, recording it if
+// not.
+func (c *seenCache) checkAndAdd(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.index[key]; ok {
+		return true
+	}
+
+	c.index[key] = struct{}{}
+	c.order = append(c.order, key)
+	if len(c.order) > c.capacity {
+		oldest := c.order[0]
+		c.order = c.order[1:]
+		delete(c.index, oldest)
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+// Package callbacks verifies inbound signed webhook callbacks, the
+// counterpart to internal/channels' outbound webhook signing.
+package callbacks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider selects the inbound signature header format Verify parses -
+// Stripe, GitHub, and Slack each sign callbacks differently.
+type Provider string
+
+const (
+	ProviderStripe   Provider = "stripe"   // X-Signature: t=<unix>,v1=<hex>[,v1=<hex>...]
+	ProviderGitHub   Provider = "github"   // X-Hub-Signature-256: sha256=<hex>
+	ProviderSlack    Provider = "slack"    // X-Slack-Signature: v0=<hex>, X-Slack-Request-Timestamp: <unix>
+	ProviderInternal Provider = "internal" // X-Signature-256: <hex>, X-Signature-Timestamp: <unix>
+)
+
+// DefaultMaxAge bounds how old a signing timestamp may be before Verify
+// rejects it as a possible replay.
+const DefaultMaxAge = 5 * time.Minute
+
+// Config configures signature verification for one callback route.
+type Config struct {
+	Provider Provider
+	// Secrets holds every currently-valid signing secret. A signature
+	// matching any one of them is accepted, so a secret can be rotated by
+	// adding the new one here before removing the old. Ignored once
+	// SecretsFunc is set.
+	Secrets []string
+	// SecretsFunc, if set, is called on every Verify to fetch the
+	// currently-valid secrets instead of using the static Secrets field -
+	// e.g. reading SQLiteStore's callback_secrets table, so rotating a
+	// secret via RotateCallbackSecret takes effect immediately without
+	// restarting the process. An error is treated as "no valid secrets".
+	SecretsFunc func() ([]string, error)
+	// MaxAge bounds signing-timestamp age; zero uses DefaultMaxAge.
+	MaxAge time.Duration
+}
+
+// errVerificationFailed is the single error Verify ever returns - for a
+// malformed header, a stale timestamp, a replay, or a signature mismatch
+// alike - so a caller responding 401 can't leak which check failed.
+var errVerificationFailed = fmt.Errorf("callback signature verification failed")
+
+// Verifier checks inbound callback signatures against Config.Secrets,
+// enforces a timestamp freshness window, and rejects replay of an
+// already-seen (id, timestamp, signature) tuple within that window.
+type Verifier struct {
+	cfg  Config
+	seen *seenCache
+}
+
+// NewVerifier creates a Verifier. capacity bounds how many (id, timestamp,
+// signature) tuples the replay cache remembers before evicting the oldest
+// to make room; zero uses a default of 4096.
+func NewVerifier(cfg Config, capacity int) *Verifier {
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultMaxAge
+	}
+	if capacity <= 0 {
+		capacity = 4096
+	}
+	return &Verifier{cfg: cfg, seen: newSeenCache(capacity)}
+}
+
+// Verify checks id/header/body against v.cfg. It returns nil only if the
+// signature matches one of v.cfg.Secrets, the timestamp (for providers that
+// carry one) is within MaxAge, and the (id, timestamp, signature) tuple
+// hasn't been seen before.
+func (v *Verifier) Verify(id string, header http.Header, body []byte) error {
+	spec, ok := providerSpecs[v.cfg.Provider]
+	if !ok {
+		return errVerificationFailed
+	}
+
+	timestamp, hasTimestamp, macs, err := spec.parse(header)
+	if err != nil || len(macs) == 0 {
+		return errVerificationFailed
+	}
+
+	if hasTimestamp {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age < 0 {
+			age = -age
+		}
+		if age > v.cfg.MaxAge {
+			return errVerificationFailed
+		}
+	}
+
+	secrets := v.cfg.Secrets
+	if v.cfg.SecretsFunc != nil {
+		fetched, err := v.cfg.SecretsFunc()
+		if err != nil {
+			return errVerificationFailed
+		}
+		secrets = fetched
+	}
+
+	signed := spec.signedMessage(timestamp, body)
+
+	valid := false
+	for _, secret := range secrets {
+		expected := hmacHex(secret, signed)
+		for _, mac := range macs {
+			if subtle.ConstantTimeCompare([]byte(expected), []byte(mac)) == 1 {
+				valid = true
+			}
+		}
+	}
+	if !valid {
+		return errVerificationFailed
+	}
+
+	replayKey := fmt.Sprintf("%s:%d:%s", id, timestamp, macs[0])
+	if v.seen.checkAndAdd(replayKey) {
+		return errVerificationFailed
+	}
+
+	return nil
+}
+
+func hmacHex(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// providerSpec describes how to extract a timestamp (if any) and one or
+// more candidate MACs from a provider's header, and how to reconstruct the
+// exact bytes that were signed.
+type providerSpec struct {
+	parse         func(h http.Header) (timestamp int64, hasTimestamp bool, macs []string, err error)
+	signedMessage func(timestamp int64, body []byte) string
+}
+
+var providerSpecs = map[Provider]providerSpec{
+	ProviderStripe: {
+		parse: parseStripeSignature,
+		signedMessage: func(timestamp int64, body []byte) string {
+			return fmt.Sprintf("%d.%s", timestamp, body)
+		},
+	},
+	ProviderGitHub: {
+		parse: parseGitHubSignature,
+		signedMessage: func(_ int64, body []byte) string {
+			return string(body)
+		},
+	},
+	ProviderSlack: {
+		parse: parseSlackSignature,
+		signedMessage: func(timestamp int64, body []byte) string {
+			return fmt.Sprintf("v0:%d:%s", timestamp, body)
+		},
+	},
+	ProviderInternal: {
+		parse: parseInternalSignature,
+		signedMessage: func(timestamp int64, body []byte) string {
+			return fmt.Sprintf("v0:%d:%s", timestamp, body)
+		},
+	},
+}
+
+// parseStripeSignature parses "t=<unix>,v1=<hex>[,v1=<hex>...]", returning
+// every v1 value (Stripe sends one per secret while a rotation is live).
+func parseStripeSignature(h http.Header) (int64, bool, []string, error) {
+	header := h.Get("X-Signature")
+	if header == "" {
+		return 0, false, nil, fmt.Errorf("missing X-Signature")
+	}
+
+	var timestamp int64
+	var macs []string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return 0, false, nil, err
+			}
+			timestamp = ts
+		case "v1":
+			macs = append(macs, kv[1])
+		}
+	}
+	if timestamp == 0 || len(macs) == 0 {
+		return 0, false, nil, fmt.Errorf("malformed X-Signature")
+	}
+	return timestamp, true, macs, nil
+}
+
+// parseGitHubSignature parses "sha256=<hex>". GitHub doesn't put a
+// timestamp in this header, so Verify skips the freshness check for this
+// provider and relies on the replay cache alone.
+func parseGitHubSignature(h http.Header) (int64, bool, []string, error) {
+	header := h.Get("X-Hub-Signature-256")
+	const prefix = "sha256="
+	if !strings.HasPrefix(header, prefix) {
+		return 0, false, nil, fmt.Errorf("missing X-Hub-Signature-256")
+	}
+	return 0, false, []string{strings.TrimPrefix(header, prefix)}, nil
+}
+
+// parseSlackSignature parses "v0=<hex>" from X-Slack-Signature and the
+// companion X-Slack-Request-Timestamp header.
+func parseSlackSignature(h http.Header) (int64, bool, []string, error) {
+	sigHeader := h.Get("X-Slack-Signature")
+	const prefix = "v0="
+	if !strings.HasPrefix(sigHeader, prefix) {
+		return 0, false, nil, fmt.Errorf("missing X-Slack-Signature")
+	}
+
+	ts := h.Get("X-Slack-Request-Timestamp")
+	timestamp, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return 0, false, nil, fmt.Errorf("missing or malformed X-Slack-Request-Timestamp")
+	}
+
+	return timestamp, true, []string{strings.TrimPrefix(sigHeader, prefix)}, nil
+}
+
+// parseInternalSignature parses this service's own X-Signature-256 (a bare
+// hex HMAC, no "v0=" prefix) and companion X-Signature-Timestamp header -
+// the same "v0:timestamp:body" scheme as ProviderSlack, under header names
+// that don't collide with an actual upstream provider's.
+func parseInternalSignature(h http.Header) (int64, bool, []string, error) {
+	mac := h.Get("X-Signature-256")
+	if mac == "" {
+		return 0, false, nil, fmt.Errorf("missing X-Signature-256")
+	}
+
+	ts := h.Get("X-Signature-Timestamp")
+	timestamp, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return 0, false, nil, fmt.Errorf("missing or malformed X-Signature-Timestamp")
+	}
+
+	return timestamp, true, []string{mac}, nil
+}
@@ -0,0 +1,199 @@
+package callbacks
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const testSecret = "s3cr3t"
+
+func stripeHeaders(secret string, ts int64, body []byte) http.Header {
+	signed := stripeSignedMessage(ts, body)
+	h := http.Header{}
+	h.Set("X-Signature", "t="+strconv.FormatInt(ts, 10)+",v1="+hmacHex(secret, signed))
+	return h
+}
+
+func stripeSignedMessage(ts int64, body []byte) string {
+	return providerSpecs[ProviderStripe].signedMessage(ts, body)
+}
+
+func githubHeaders(secret string, body []byte) http.Header {
+	h := http.Header{}
+	h.Set("X-Hub-Signature-256", "sha256="+hmacHex(secret, string(body)))
+	return h
+}
+
+func slackHeaders(secret string, ts int64, body []byte) http.Header {
+	signed := providerSpecs[ProviderSlack].signedMessage(ts, body)
+	h := http.Header{}
+	h.Set("X-Slack-Signature", "v0="+hmacHex(secret, signed))
+	h.Set("X-Slack-Request-Timestamp", strconv.FormatInt(ts, 10))
+	return h
+}
+
+func internalHeaders(secret string, ts int64, body []byte) http.Header {
+	signed := providerSpecs[ProviderInternal].signedMessage(ts, body)
+	h := http.Header{}
+	h.Set("X-Signature-256", hmacHex(secret, signed))
+	h.Set("X-Signature-Timestamp", strconv.FormatInt(ts, 10))
+	return h
+}
+
+func TestVerify_PerProviderValidSignature(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	now := time.Now().Unix()
+
+	cases := []struct {
+		name     string
+		provider Provider
+		headers  http.Header
+	}{
+		{"stripe", ProviderStripe, stripeHeaders(testSecret, now, body)},
+		{"github", ProviderGitHub, githubHeaders(testSecret, body)},
+		{"slack", ProviderSlack, slackHeaders(testSecret, now, body)},
+		{"internal", ProviderInternal, internalHeaders(testSecret, now, body)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			v := NewVerifier(Config{Provider: c.provider, Secrets: []string{testSecret}}, 0)
+			if err := v.Verify("req-"+c.name, c.headers, body); err != nil {
+				t.Errorf("expected valid %s signature to verify, got: %v", c.name, err)
+			}
+		})
+	}
+}
+
+func TestVerify_WrongSecretRejected(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	now := time.Now().Unix()
+	headers := internalHeaders("wrong-secret", now, body)
+
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret}}, 0)
+	if err := v.Verify("req-1", headers, body); err == nil {
+		t.Error("expected signature signed with the wrong secret to be rejected")
+	}
+}
+
+func TestVerify_TamperedBodyRejected(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	now := time.Now().Unix()
+	headers := internalHeaders(testSecret, now, body)
+
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret}}, 0)
+	tampered := []byte(`{"event":"pong"}`)
+	if err := v.Verify("req-1", headers, tampered); err == nil {
+		t.Error("expected a signature over a different body to be rejected")
+	}
+}
+
+func TestVerify_StaleTimestampRejected(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	stale := time.Now().Add(-10 * time.Minute).Unix()
+	headers := internalHeaders(testSecret, stale, body)
+
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret}, MaxAge: 5 * time.Minute}, 0)
+	if err := v.Verify("req-1", headers, body); err == nil {
+		t.Error("expected a timestamp older than MaxAge to be rejected")
+	}
+}
+
+func TestVerify_FutureTimestampRejected(t *testing.T) {
+	// A timestamp skewed into the future (clock drift, or a forged replay
+	// attempt) should be rejected the same as a stale one - Verify takes
+	// the absolute age, not just "too old".
+	body := []byte(`{"event":"ping"}`)
+	future := time.Now().Add(10 * time.Minute).Unix()
+	headers := internalHeaders(testSecret, future, body)
+
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret}, MaxAge: 5 * time.Minute}, 0)
+	if err := v.Verify("req-1", headers, body); err == nil {
+		t.Error("expected a timestamp skewed into the future to be rejected")
+	}
+}
+
+func TestVerify_WithinMaxAgeAccepted(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	recent := time.Now().Add(-2 * time.Minute).Unix()
+	headers := internalHeaders(testSecret, recent, body)
+
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret}, MaxAge: 5 * time.Minute}, 0)
+	if err := v.Verify("req-1", headers, body); err != nil {
+		t.Errorf("expected a timestamp within MaxAge to be accepted, got: %v", err)
+	}
+}
+
+func TestVerify_ReplayRejected(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	now := time.Now().Unix()
+	headers := internalHeaders(testSecret, now, body)
+
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret}}, 0)
+	if err := v.Verify("req-1", headers, body); err != nil {
+		t.Fatalf("first delivery should verify, got: %v", err)
+	}
+	if err := v.Verify("req-1", headers, body); err == nil {
+		t.Error("expected replaying the exact same (id, timestamp, signature) to be rejected")
+	}
+}
+
+func TestVerify_SameSignatureDifferentIDNotTreatedAsReplay(t *testing.T) {
+	// The replay key includes the caller-supplied id, so two distinct
+	// delivery attempts (e.g. re-sent by a provider under a new delivery
+	// id) aren't conflated just because they happen to share a signature.
+	body := []byte(`{"event":"ping"}`)
+	now := time.Now().Unix()
+	headers := internalHeaders(testSecret, now, body)
+
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret}}, 0)
+	if err := v.Verify("req-1", headers, body); err != nil {
+		t.Fatalf("first delivery should verify, got: %v", err)
+	}
+	if err := v.Verify("req-2", headers, body); err != nil {
+		t.Errorf("expected a different id with the same signature to verify, got: %v", err)
+	}
+}
+
+func TestVerify_SecretRotationAcceptsEitherSecret(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	now := time.Now().Unix()
+	headers := internalHeaders("new-secret", now, body)
+
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret, "new-secret"}}, 0)
+	if err := v.Verify("req-1", headers, body); err != nil {
+		t.Errorf("expected a signature from either currently-valid secret to verify, got: %v", err)
+	}
+}
+
+func TestVerify_MalformedHeaderRejected(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	v := NewVerifier(Config{Provider: ProviderInternal, Secrets: []string{testSecret}}, 0)
+
+	h := http.Header{}
+	h.Set("X-Signature-256", "not-a-valid-hmac")
+	// Missing X-Signature-Timestamp entirely.
+	if err := v.Verify("req-1", h, body); err == nil {
+		t.Error("expected a missing timestamp header to be rejected")
+	}
+}
+
+func TestVerify_UnknownProviderRejected(t *testing.T) {
+	v := NewVerifier(Config{Provider: Provider("unknown"), Secrets: []string{testSecret}}, 0)
+	if err := v.Verify("req-1", http.Header{}, []byte("body")); err == nil {
+		t.Error("expected an unconfigured provider to be rejected")
+	}
+}
+
+func TestVerify_GitHubHasNoFreshnessCheck(t *testing.T) {
+	// GitHub's header carries no timestamp, so an old signature (assuming
+	// it hasn't already been replayed) must still verify - Verify relies
+	// solely on the replay cache for this provider.
+	body := []byte(`{"event":"ping"}`)
+	v := NewVerifier(Config{Provider: ProviderGitHub, Secrets: []string{testSecret}}, 0)
+	if err := v.Verify("req-1", githubHeaders(testSecret, body), body); err != nil {
+		t.Errorf("expected a valid GitHub signature to verify regardless of age, got: %v", err)
+	}
+}
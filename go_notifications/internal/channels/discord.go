@@ -0,0 +1,113 @@
+// Package channels - Discord incoming webhook notification channel
+package channels
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// DiscordConfig configures a Discord incoming webhook provider.
+type DiscordConfig struct {
+	WebhookURL string
+}
+
+// DiscordChannel sends notifications through a Discord incoming webhook.
+type DiscordChannel struct {
+	cfg    DiscordConfig
+	policy WebhookPolicy
+	client *http.Client
+}
+
+// NewDiscordChannel validates cfg and returns a DiscordChannel, or an
+// error if webhook_url is missing. Outbound requests go through a client
+// built by NewSecureHTTPClient, the same SSRF hardening WebhookChannel
+// uses, since n.Recipient may override cfg.WebhookURL at Send time.
+func NewDiscordChannel(cfg DiscordConfig) (*DiscordChannel, error) {
+	if cfg.WebhookURL == "" {
+		return nil, fmt.Errorf("discord: webhook_url is required")
+	}
+
+	policy := DefaultWebhookPolicy()
+	return &DiscordChannel{
+		cfg:    cfg,
+		policy: policy,
+		client: NewSecureHTTPClient(policy),
+	}, nil
+}
+
+// Name returns the channel name
+func (d *DiscordChannel) Name() string {
+	return "discord"
+}
+
+// Validate checks if the notification is valid for Discord
+func (d *DiscordChannel) Validate(n *Notification) error {
+	return nil
+}
+
+// Parse builds a Sender for a "discord://WEBHOOK_ID@WEBHOOK_TOKEN"
+// notification-url, combining the two into a Discord incoming webhook
+// URL.
+func (d *DiscordChannel) Parse(u *url.URL) (Sender, error) {
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("discord notification-url requires a webhook id, e.g. discord://ID@TOKEN")
+	}
+	token := u.Hostname()
+	if token == "" {
+		return nil, fmt.Errorf("discord notification-url requires a webhook token, e.g. discord://ID@TOKEN")
+	}
+
+	webhookURL := fmt.Sprintf("https://discord.com/api/webhooks/%s/%s", u.User.Username(), token)
+	ch, err := NewDiscordChannel(DiscordConfig{WebhookURL: webhookURL})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func init() {
+	Register("discord", (&DiscordChannel{}).Parse)
+}
+
+// Send posts the message to the Discord webhook. n.Recipient, if set,
+// overrides the configured webhook URL - validated through
+// validateWebhookURL the same way WebhookChannel.ValidateWebhookURL
+// validates a recipient, so an override can't be pointed at an internal
+// address.
+func (d *DiscordChannel) Send(n *Notification) (map[string]interface{}, error) {
+	webhookURL := d.cfg.WebhookURL
+	if n.Recipient != "" {
+		webhookURL = n.Recipient
+	}
+	if err := validateWebhookURL(d.policy, webhookURL); err != nil {
+		return nil, fmt.Errorf("invalid discord webhook URL: %w", err)
+	}
+
+	content := n.Message
+	if n.Subject != "" {
+		content = fmt.Sprintf("**%s**\n%s", n.Subject, n.Message)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"content": content})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("discord returned status %d", resp.StatusCode)
+	}
+
+	return map[string]interface{}{
+		"sent_to":     webhookURL,
+		"status_code": resp.StatusCode,
+	}, nil
+}
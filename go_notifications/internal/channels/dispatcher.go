@@ -2,41 +2,108 @@
 package channels
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 )
 
-// Dispatcher routes notifications to the appropriate channel
+// ErrUnknownChannel is wrapped into the error Dispatch returns when
+// Notification.Channel names neither a built Notifier nor a registered
+// Channel, so a caller (e.g. an HTTP handler) can tell "bad input" apart
+// from a channel's own send failure with errors.Is and answer 400 instead
+// of 500.
+var ErrUnknownChannel = errors.New("unknown channel")
+
+// Dispatcher routes notifications to the appropriate provider, looked up
+// by Notification.Channel. Slack, webhook, email, and file are looked up
+// in notifiers, the factory-built Notifier registry; everything else
+// (SMS, Telegram, Discord, push, script, ...) is looked up in the older
+// Channel-based registry, so new providers can still be added to either
+// without editing Dispatch.
 type Dispatcher struct {
-	email   *EmailChannel
+	notifiers *NotifierRegistry
+	registry  *Registry
+
+	// webhook is kept alongside the registries because callers like
+	// TestWebhook and Worker's webhook job type need WebhookChannel's
+	// extra methods (SendToURL, FetchURLContent), which aren't part of
+	// the Notifier or Channel interfaces.
 	webhook *WebhookChannel
-	slack   *SlackChannel
-	file    *FileChannel
+
+	slackWorkspacesMu sync.RWMutex
+	slackWorkspaces   map[string]*SlackChannel
 }
 
-// NewDispatcher creates a new notification dispatcher
-func NewDispatcher(email *EmailChannel, webhook *WebhookChannel, slack *SlackChannel, file *FileChannel) *Dispatcher {
+// NewDispatcher creates a new notification dispatcher backed by notifiers
+// and registry. webhook is typically the same instance wrapped by the
+// "webhook" entry in notifiers.
+func NewDispatcher(notifiers *NotifierRegistry, registry *Registry, webhook *WebhookChannel) *Dispatcher {
 	return &Dispatcher{
-		email:   email,
-		webhook: webhook,
-		slack:   slack,
-		file:    file,
+		notifiers:       notifiers,
+		registry:        registry,
+		webhook:         webhook,
+		slackWorkspaces: make(map[string]*SlackChannel),
 	}
 }
 
-// Dispatch sends a notification via the appropriate channel
+// RegisterSlackWorkspace adds a named Slack workspace webhook, selectable
+// by setting Notification.Metadata["workspace"] to name - an alternative
+// to SlackChannel.AllowRecipientAsURL for callers that need to post to
+// more than one workspace without encoding a webhook URL into every
+// notification. Calling it again for the same name replaces the webhook
+// it points at.
+func (d *Dispatcher) RegisterSlackWorkspace(name, webhookURL string) {
+	d.slackWorkspacesMu.Lock()
+	defer d.slackWorkspacesMu.Unlock()
+	d.slackWorkspaces[name] = NewSlackChannel(webhookURL)
+}
+
+// Dispatch sends a notification. If n.Target is set, it is parsed as a
+// notification-url via ParseTarget and takes priority over n.Channel -
+// the URL's scheme picks the Sender and the URL itself is the whole
+// destination, so Recipient is ignored on that path. Otherwise, a
+// "slack" channel with Metadata["workspace"] set is routed to the
+// matching RegisterSlackWorkspace entry instead of the "slack" notifier/
+// registry entry. Otherwise, Dispatch looks up n.Channel in notifiers
+// first, then in the legacy registry; a name in neither returns
+// ErrUnknownChannel.
 func (d *Dispatcher) Dispatch(n *Notification) (map[string]interface{}, error) {
-	switch n.Channel {
-	case "email":
-		return d.email.Send(n)
-	case "webhook":
-		return d.webhook.Send(n)
-	case "slack":
-		return d.slack.Send(n)
-	case "file":
-		return d.file.Send(n)
-	default:
-		return nil, fmt.Errorf("unknown channel: %s", n.Channel)
+	if n.Target != "" {
+		sender, err := ParseTarget(n.Target)
+		if err != nil {
+			return nil, err
+		}
+		return sender.Send(n)
+	}
+
+	if n.Channel == "slack" {
+		if workspace := n.Metadata["workspace"]; workspace != "" {
+			d.slackWorkspacesMu.RLock()
+			ch, ok := d.slackWorkspaces[workspace]
+			d.slackWorkspacesMu.RUnlock()
+			if !ok {
+				return nil, fmt.Errorf("slack workspace %q is not registered", workspace)
+			}
+			if err := ch.Validate(n); err != nil {
+				return nil, err
+			}
+			return ch.Send(n)
+		}
+	}
+
+	if notifier, ok := d.notifiers.Get(n.Channel); ok {
+		return notifier.Send(context.Background(), n)
+	}
+
+	ch, ok := d.registry.Get(n.Channel)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, n.Channel)
+	}
+	if err := ch.Validate(n); err != nil {
+		return nil, err
 	}
+	return ch.Send(n)
 }
 
 // WebhookChannel returns the webhook channel for direct access
@@ -44,18 +111,19 @@ func (d *Dispatcher) WebhookChannel() *WebhookChannel {
 	return d.webhook
 }
 
-// DispatchMulti sends to multiple channels
-func (d *Dispatcher) DispatchMulti(n *Notification, channels []string) []DeliveryResult {
-	results := make([]DeliveryResult, 0, len(channels))
+// DispatchMulti sends n to every notification-url in targets, e.g.
+// []string{"slack://TOKEN@workspace/general", "smtp://host/?to=a@b.com"}.
+func (d *Dispatcher) DispatchMulti(n *Notification, targets []string) []DeliveryResult {
+	results := make([]DeliveryResult, 0, len(targets))
 
-	for _, ch := range channels {
+	for _, target := range targets {
 		notifCopy := *n
-		notifCopy.Channel = ch
+		notifCopy.Target = target
 
 		result, err := d.Dispatch(&notifCopy)
 
 		dr := DeliveryResult{
-			Channel:  ch,
+			Channel:  target,
 			Success:  err == nil,
 			Response: result,
 		}
@@ -0,0 +1,67 @@
+package channels
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// DKIMSigner holds the key material Message.Build uses to add a
+// DKIM-Signature header, using the "simple" canonicalization algorithm
+// (RFC 6376 section 3.4.3): header and body bytes are included as-is,
+// except the body's trailing blank lines are trimmed to a single CRLF.
+type DKIMSigner struct {
+	Domain     string
+	Selector   string
+	PrivateKey *rsa.PrivateKey
+
+	// Headers lists, in order, the header field names to sign. A name
+	// with no matching header in the message is skipped.
+	Headers []string
+}
+
+// Sign computes a DKIM-Signature header value over headerLines (the
+// "Name: value" pairs Build is about to emit) and body.
+func (d *DKIMSigner) Sign(headerLines map[string]string, body []byte) (string, error) {
+	bodyHash := sha256.Sum256(canonicalizeBodySimple(body))
+
+	var signed []string
+	var canon strings.Builder
+	for _, name := range d.Headers {
+		value, ok := headerLines[name]
+		if !ok {
+			continue
+		}
+		canon.WriteString(name)
+		canon.WriteString(": ")
+		canon.WriteString(value)
+		canon.WriteString("\r\n")
+		signed = append(signed, name)
+	}
+
+	tag := fmt.Sprintf(
+		"v=1; a=rsa-sha256; c=simple/simple; d=%s; s=%s; h=%s; bh=%s; b=",
+		d.Domain, d.Selector, strings.Join(signed, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]),
+	)
+	canon.WriteString("DKIM-Signature: ")
+	canon.WriteString(tag)
+
+	digest := sha256.Sum256([]byte(canon.String()))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, d.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("dkim: sign: %w", err)
+	}
+
+	return tag + base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// canonicalizeBodySimple trims trailing blank lines from body and ensures
+// it ends in exactly one CRLF, per the "simple" body canonicalization rule.
+func canonicalizeBodySimple(body []byte) []byte {
+	trimmed := strings.TrimRight(string(body), "\r\n")
+	return []byte(trimmed + "\r\n")
+}
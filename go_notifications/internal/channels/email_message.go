@@ -0,0 +1,276 @@
+package channels
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Attachment is a file Message.Build attaches as its own MIME part,
+// base64-encoded with a Content-Disposition: attachment header.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message builds an RFC 5322 email via net/mail and mime/multipart instead
+// of concatenating a "From: ...\r\n" string by hand, so header values go
+// through validateHeaderValue and multipart structure is generated rather
+// than hand-formatted.
+type Message struct {
+	from        mail.Address
+	to          []mail.Address
+	subject     string
+	text        string
+	html        string
+	attachments []Attachment
+	headers     map[string]string
+	dkim        *DKIMSigner
+}
+
+// NewMessage creates a Message from from/to addresses and a subject.
+// subject is validated by validateHeaderValue. from and to are not checked
+// here: Build writes them through mail.Address.String(), so they must come
+// from mail.ParseAddress (as Send's do, via ParseRecipient), which rejects
+// CR/LF.
+func NewMessage(from mail.Address, to []mail.Address, subject string) (*Message, error) {
+	if len(to) == 0 {
+		return nil, fmt.Errorf("message requires at least one recipient")
+	}
+	if err := validateHeaderValue(subject); err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	return &Message{from: from, to: to, subject: subject, headers: map[string]string{}}, nil
+}
+
+// ParseRecipient validates address the same way Send must before handing it
+// to SMTP's RCPT TO: a well-formed RFC 5322 address, not merely non-empty.
+func ParseRecipient(address string) (mail.Address, error) {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return mail.Address{}, fmt.Errorf("invalid recipient address %q: %w", address, err)
+	}
+	return *addr, nil
+}
+
+// SetText sets the plain-text body.
+func (m *Message) SetText(body string) { m.text = body }
+
+// SetHTML sets the HTML body. When both SetText and SetHTML are set, Build
+// emits a multipart/alternative part carrying both.
+func (m *Message) SetHTML(body string) { m.html = body }
+
+// AddAttachment attaches data as filename/contentType.
+func (m *Message) AddAttachment(filename, contentType string, data []byte) error {
+	if err := validateHeaderValue(filename); err != nil {
+		return fmt.Errorf("attachment filename: %w", err)
+	}
+	m.attachments = append(m.attachments, Attachment{Filename: filename, ContentType: contentType, Data: data})
+	return nil
+}
+
+// AddHeader sets an extra header, rejecting a name or value containing CR,
+// LF, or a bare NUL - the building blocks of header injection - since
+// Build writes name unsanitized the same as value.
+func (m *Message) AddHeader(name, value string) error {
+	if err := validateHeaderName(name); err != nil {
+		return fmt.Errorf("header name %q: %w", name, err)
+	}
+	if err := validateHeaderValue(value); err != nil {
+		return fmt.Errorf("header %q: %w", name, err)
+	}
+	m.headers[name] = value
+	return nil
+}
+
+// SetDKIM configures a signer Build uses to add a DKIM-Signature header.
+func (m *Message) SetDKIM(signer *DKIMSigner) { m.dkim = signer }
+
+// validateHeaderValue rejects CR, LF, and NUL: the characters an attacker
+// needs to terminate a header line early and inject their own.
+func validateHeaderValue(v string) error {
+	if strings.ContainsAny(v, "\r\n\x00") {
+		return fmt.Errorf("value contains a disallowed control character")
+	}
+	return nil
+}
+
+// validateHeaderName rejects the same disallowed control characters as
+// validateHeaderValue, plus ':' - a header name can't contain one, so an
+// injected ":" would itself start forging a second header.
+func validateHeaderName(name string) error {
+	if err := validateHeaderValue(name); err != nil {
+		return err
+	}
+	if strings.Contains(name, ":") {
+		return fmt.Errorf("name contains ':'")
+	}
+	return nil
+}
+
+// Build renders the message as RFC 5322 bytes, ready for an SMTP DATA
+// command or a sendmail-style transport's stdin.
+func (m *Message) Build() ([]byte, error) {
+	toAddrs := make([]string, len(m.to))
+	for i, a := range m.to {
+		toAddrs[i] = a.String()
+	}
+
+	headerLines := map[string]string{
+		"From":    m.from.String(),
+		"To":      strings.Join(toAddrs, ", "),
+		"Subject": mime.QEncoding.Encode("utf-8", m.subject),
+		"Date":    time.Now().Format(time.RFC1123Z),
+	}
+	order := []string{"From", "To", "Subject", "Date"}
+	for name, value := range m.headers {
+		headerLines[name] = value
+		order = append(order, name)
+	}
+
+	var body bytes.Buffer
+	var bodyContentType string
+
+	switch {
+	case len(m.attachments) > 0:
+		mw := multipart.NewWriter(&body)
+		bodyContentType = fmt.Sprintf("multipart/mixed; boundary=%q", mw.Boundary())
+
+		altBuf, altType, err := m.buildAlternative()
+		if err != nil {
+			return nil, err
+		}
+		altPart, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {altType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := altPart.Write(altBuf); err != nil {
+			return nil, err
+		}
+
+		for _, att := range m.attachments {
+			if err := writeAttachmentPart(mw, att); err != nil {
+				return nil, err
+			}
+		}
+		if err := mw.Close(); err != nil {
+			return nil, err
+		}
+
+	case m.text != "" && m.html != "":
+		altBuf, altType, err := m.buildAlternative()
+		if err != nil {
+			return nil, err
+		}
+		body.Write(altBuf)
+		bodyContentType = altType
+
+	case m.html != "":
+		bodyContentType = "text/html; charset=UTF-8"
+		body.WriteString(m.html)
+
+	default:
+		bodyContentType = "text/plain; charset=UTF-8"
+		body.WriteString(m.text)
+	}
+
+	headerLines["MIME-Version"] = "1.0"
+	headerLines["Content-Type"] = bodyContentType
+	order = append(order, "MIME-Version", "Content-Type")
+
+	if m.dkim != nil {
+		sig, err := m.dkim.Sign(headerLines, body.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		headerLines["DKIM-Signature"] = sig
+		order = append([]string{"DKIM-Signature"}, order...)
+	}
+
+	var out bytes.Buffer
+	for _, name := range order {
+		fmt.Fprintf(&out, "%s: %s\r\n", name, headerLines[name])
+	}
+	out.WriteString("\r\n")
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// buildAlternative renders m.text and/or m.html as a single part (if only
+// one is set) or a multipart/alternative part (if both are).
+func (m *Message) buildAlternative() ([]byte, string, error) {
+	if m.text == "" && m.html == "" {
+		return nil, "", fmt.Errorf("message has no body")
+	}
+	if m.html == "" {
+		return []byte(m.text), "text/plain; charset=UTF-8", nil
+	}
+	if m.text == "" {
+		return []byte(m.html), "text/html; charset=UTF-8", nil
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	contentType := fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary())
+
+	textPart, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := textPart.Write([]byte(m.text)); err != nil {
+		return nil, "", err
+	}
+
+	htmlPart, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/html; charset=UTF-8"}})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := htmlPart.Write([]byte(m.html)); err != nil {
+		return nil, "", err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+// writeAttachmentPart writes att as a base64-encoded MIME part, wrapped at
+// the conventional 76-character line length.
+func writeAttachmentPart(mw *multipart.Writer, att Attachment) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {att.ContentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", att.Filename)},
+	}
+	w, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(att.Data)))
+	base64.StdEncoding.Encode(encoded, att.Data)
+
+	const lineLength = 76
+	for i := 0; i < len(encoded); i += lineLength {
+		end := i + lineLength
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		if _, err := w.Write(encoded[i:end]); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte("\r\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,8 +2,10 @@
 package channels
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -37,6 +39,78 @@ func (f *FileChannel) Validate(n *Notification) error {
 	return nil
 }
 
+// Parse builds a Sender for a "file:///var/log/notify.log"
+// notification-url: the directory becomes the Sender's logDir and the
+// base name becomes the fixed filename Send always appends to,
+// inheriting the same path-traversal behavior as Send/n.Recipient today.
+func (f *FileChannel) Parse(u *url.URL) (Sender, error) {
+	if u.Path == "" {
+		return nil, fmt.Errorf("file notification-url requires a path, e.g. file:///var/log/notify.log")
+	}
+	return &boundFileSender{
+		channel:  NewFileChannel(filepath.Dir(u.Path)),
+		filename: filepath.Base(u.Path),
+	}, nil
+}
+
+// boundFileSender adapts FileChannel to Sender for a Parse-built
+// destination: Send always writes to filename rather than reading it off
+// Notification.Recipient.
+type boundFileSender struct {
+	channel  *FileChannel
+	filename string
+}
+
+func (b *boundFileSender) Send(n *Notification) (map[string]interface{}, error) {
+	notifCopy := *n
+	notifCopy.Recipient = b.filename
+	return b.channel.Send(&notifCopy)
+}
+
+func init() {
+	Register("file", (&FileChannel{}).Parse)
+	RegisterNotifier("file", newFileNotifier)
+}
+
+// FileConfig is FileChannel's strongly-typed factory config, used by the
+// "file" notifier factory registered below.
+type FileConfig struct {
+	// LogDir is the directory Send appends to; created if missing.
+	LogDir string `json:"log_dir"`
+}
+
+// fileNotifier adapts FileChannel to the Notifier interface for the
+// registry-driven factory path.
+type fileNotifier struct {
+	*FileChannel
+}
+
+func newFileNotifier(cfg json.RawMessage) (Notifier, error) {
+	var c FileConfig
+	if err := json.Unmarshal(cfg, &c); err != nil {
+		return nil, fmt.Errorf("invalid file config: %w", err)
+	}
+	if c.LogDir == "" {
+		return nil, fmt.Errorf("file config requires log_dir")
+	}
+	return &fileNotifier{FileChannel: NewFileChannel(c.LogDir)}, nil
+}
+
+func (f *fileNotifier) Validate(cfg json.RawMessage) error {
+	var c FileConfig
+	if err := json.Unmarshal(cfg, &c); err != nil {
+		return err
+	}
+	if c.LogDir == "" {
+		return fmt.Errorf("file config requires log_dir")
+	}
+	return nil
+}
+
+func (f *fileNotifier) Send(ctx context.Context, n *Notification) (Result, error) {
+	return f.FileChannel.Send(n)
+}
+
 // Send writes the notification to a file
 // TAINT SINK: Recipient is user-controlled filename - path traversal
 func (f *FileChannel) Send(n *Notification) (map[string]interface{}, error) {
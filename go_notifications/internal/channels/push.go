@@ -0,0 +1,88 @@
+// Package channels - generic FCM push notification channel
+package channels
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PushConfig configures an FCM-compatible push provider.
+type PushConfig struct {
+	ServerKey string
+	Endpoint  string // defaults to https://fcm.googleapis.com/fcm/send
+}
+
+// PushChannel sends notifications through Firebase Cloud Messaging.
+type PushChannel struct {
+	cfg    PushConfig
+	client *http.Client
+}
+
+// NewPushChannel validates cfg and returns a PushChannel, or an error if
+// server_key is missing.
+func NewPushChannel(cfg PushConfig) (*PushChannel, error) {
+	if cfg.ServerKey == "" {
+		return nil, fmt.Errorf("push: server_key is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://fcm.googleapis.com/fcm/send"
+	}
+
+	return &PushChannel{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Name returns the channel name
+func (p *PushChannel) Name() string {
+	return "push"
+}
+
+// Validate checks if the notification is valid for push delivery
+func (p *PushChannel) Validate(n *Notification) error {
+	if n.Recipient == "" {
+		return fmt.Errorf("push: recipient device token is required")
+	}
+	return nil
+}
+
+// Send posts a message to FCM addressed at n.Recipient's device token.
+func (p *PushChannel) Send(n *Notification) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"to": n.Recipient,
+		"notification": map[string]string{
+			"title": n.Subject,
+			"body":  n.Message,
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", p.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+p.cfg.ServerKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("fcm returned status %d", resp.StatusCode)
+	}
+
+	return map[string]interface{}{
+		"to":          n.Recipient,
+		"status_code": resp.StatusCode,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package channels
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter enforces a per-channel requests-per-minute budget using a
+// token bucket that refills once per minute. It lets SendBatchNotification
+// throttle a noisy provider (e.g. SMS) without affecting the others.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]int
+	tokens  map[string]int
+	resetAt map[string]time.Time
+}
+
+// NewRateLimiter builds a RateLimiter from limits, a channel name ->
+// requests-per-minute map. A channel absent from limits, or mapped to a
+// value <= 0, is never throttled.
+func NewRateLimiter(limits map[string]int) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		tokens:  make(map[string]int),
+		resetAt: make(map[string]time.Time),
+	}
+}
+
+// Allow reports whether channel may send one more message right now,
+// consuming a token if so.
+func (rl *RateLimiter) Allow(channel string) bool {
+	limit, ok := rl.limits[channel]
+	if !ok || limit <= 0 {
+		return true
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if now.After(rl.resetAt[channel]) {
+		rl.tokens[channel] = limit
+		rl.resetAt[channel] = now.Add(time.Minute)
+	}
+
+	if rl.tokens[channel] <= 0 {
+		return false
+	}
+	rl.tokens[channel]--
+	return true
+}
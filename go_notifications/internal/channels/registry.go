@@ -0,0 +1,34 @@
+package channels
+
+// Registry maps channel names to their Channel implementation, so new
+// notification providers can be added by registering them here instead of
+// hard-coding another case into Dispatcher.Dispatch.
+type Registry struct {
+	channels map[string]Channel
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{channels: make(map[string]Channel)}
+}
+
+// Register adds ch under name, replacing any previous registration for
+// that name.
+func (r *Registry) Register(name string, ch Channel) {
+	r.channels[name] = ch
+}
+
+// Get returns the channel registered under name, if any.
+func (r *Registry) Get(name string) (Channel, bool) {
+	ch, ok := r.channels[name]
+	return ch, ok
+}
+
+// Names returns every registered channel name.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.channels))
+	for name := range r.channels {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,291 @@
+// Package channels - sandboxed script-hook channel. Operators drop a
+// script under hooks/<name>/ alongside a manifest declaring what it's
+// allowed to receive; ScriptChannel runs it with argv only (never
+// sh -c), piping notification data in as env vars and stdin rather than
+// building a command line the way FileChannel.CompressLogs/SyncToRemote
+// do.
+package channels
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envNameRe is the strict env-var name ScriptChannel requires before it
+// will forward a Metadata key as META_<KEY> - no shell metacharacters,
+// no leading digit.
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// hookNameRe is envNameRe plus '-' after the first character, so a hook
+// can be named e.g. "deploy-notify"; it still admits no '/', '.', or
+// shell metacharacters.
+var hookNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+const (
+	// maxEnvValueLen bounds a single Metadata value (and subject/message,
+	// which go through env too) before it's forwarded to the hook.
+	maxEnvValueLen = 4096
+	// maxHookOutputLen caps combined stdout+stderr retained per
+	// invocation, so a runaway hook can't exhaust memory or flood logs.
+	maxHookOutputLen = 1 << 20 // 1 MiB
+	// defaultHookTimeout applies when a hook's manifest doesn't set one.
+	defaultHookTimeout = 30 * time.Second
+)
+
+// HookManifest is hooks/<name>/hook.yaml. A hook only receives what it
+// declares here: ScriptChannel refuses to run a hook with no manifest,
+// and strips any Metadata key not present in EnvAllowlist.
+type HookManifest struct {
+	// AllowedMethods lists the notification "methods" (currently just
+	// "notify") this hook accepts; Send rejects anything else.
+	AllowedMethods []string `yaml:"allowed_methods"`
+	// Timeout bounds how long ScriptChannel waits before killing the
+	// hook. Defaults to defaultHookTimeout if zero.
+	Timeout time.Duration `yaml:"timeout"`
+	// EnvAllowlist is the set of Metadata keys forwarded as
+	// META_<UPPERCASE_K>. A key absent here is silently dropped.
+	EnvAllowlist []string `yaml:"env_allowlist"`
+	// RunAs, if set, names a system user ScriptChannel drops privileges
+	// to before exec via a syscall.Credential. Only takes effect when
+	// the server itself is running as root; otherwise it's ignored and
+	// logged once per invocation.
+	RunAs string `yaml:"run_as"`
+}
+
+// ScriptChannel invokes operator-provided scripts under hooksDir, one
+// directory per hook: hooks/<name>/hook.yaml declares the manifest and
+// hooks/<name>/run is the entrypoint ScriptChannel execs.
+type ScriptChannel struct {
+	hooksDir string
+}
+
+// NewScriptChannel creates a ScriptChannel rooted at hooksDir.
+func NewScriptChannel(hooksDir string) *ScriptChannel {
+	return &ScriptChannel{hooksDir: hooksDir}
+}
+
+// Name returns the channel name.
+func (c *ScriptChannel) Name() string {
+	return "script"
+}
+
+// Validate checks that n.Recipient names a hook with a loadable manifest
+// and an entrypoint on disk.
+func (c *ScriptChannel) Validate(n *Notification) error {
+	_, _, err := c.loadHook(n.Recipient)
+	return err
+}
+
+// Send runs the hook named by n.Recipient. See Parse for the
+// Notification.Target equivalent ("script://NAME").
+func (c *ScriptChannel) Send(n *Notification) (map[string]interface{}, error) {
+	return c.run(n.Recipient, n)
+}
+
+// Parse builds a Sender for a "script://NAME" notification-url; NAME
+// selects hooks/<NAME> the same way Send's n.Recipient does.
+func (c *ScriptChannel) Parse(u *url.URL) (Sender, error) {
+	name := u.Hostname()
+	if name == "" {
+		return nil, fmt.Errorf("script notification-url requires a hook name, e.g. script://deploy-notify")
+	}
+	return &boundScriptSender{channel: c, name: name}, nil
+}
+
+// boundScriptSender adapts ScriptChannel to Sender for a Parse-built
+// destination: Send always runs name rather than reading it off
+// Notification.Recipient.
+type boundScriptSender struct {
+	channel *ScriptChannel
+	name    string
+}
+
+func (b *boundScriptSender) Send(n *Notification) (map[string]interface{}, error) {
+	return b.channel.run(b.name, n)
+}
+
+// loadHook resolves name to hooks/<name>, confined under hooksDir, and
+// loads its manifest. name is validated against hookNameRe rather than
+// just path.Clean'd, since it also becomes part of the scratch dir name
+// and appears in log output.
+func (c *ScriptChannel) loadHook(name string) (scriptPath string, manifest HookManifest, err error) {
+	if !hookNameRe.MatchString(name) {
+		return "", HookManifest{}, fmt.Errorf("invalid hook name %q", name)
+	}
+
+	hookDir := filepath.Join(c.hooksDir, name)
+	manifestPath := filepath.Join(hookDir, "hook.yaml")
+	manifestBytes, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return "", HookManifest{}, fmt.Errorf("hook %q has no manifest: %w", name, err)
+	}
+	if err := yaml.Unmarshal(manifestBytes, &manifest); err != nil {
+		return "", HookManifest{}, fmt.Errorf("hook %q manifest is invalid: %w", name, err)
+	}
+	if manifest.Timeout <= 0 {
+		manifest.Timeout = defaultHookTimeout
+	}
+
+	scriptPath = filepath.Join(hookDir, "run")
+	if info, err := os.Stat(scriptPath); err != nil || info.IsDir() {
+		return "", HookManifest{}, fmt.Errorf("hook %q has no run entrypoint", name)
+	}
+
+	return scriptPath, manifest, nil
+}
+
+// run executes hook name's entrypoint for notification n: "notify" is
+// the only method ScriptChannel issues today, so it must appear in the
+// hook's AllowedMethods.
+func (c *ScriptChannel) run(name string, n *Notification) (map[string]interface{}, error) {
+	const method = "notify"
+
+	scriptPath, manifest, err := c.loadHook(name)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(manifest.AllowedMethods, method) {
+		return nil, fmt.Errorf("hook %q does not allow method %q", name, method)
+	}
+
+	scratchDir, err := os.MkdirTemp("", "hook-"+name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch dir for hook %q: %w", name, err)
+	}
+	defer os.RemoveAll(scratchDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), manifest.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, scriptPath)
+	cmd.Dir = scratchDir
+	cmd.Env = hookEnv(name, method, n, manifest.EnvAllowlist)
+	cmd.Stdin = strings.NewReader(n.Message)
+
+	var output bytes.Buffer
+	cmd.Stdout = &capWriter{buf: &output, limit: maxHookOutputLen}
+	cmd.Stderr = &capWriter{buf: &output, limit: maxHookOutputLen}
+
+	if cred, ok := credentialFor(manifest.RunAs); ok {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+	} else if manifest.RunAs != "" {
+		log.Printf("script channel: hook %q requested run_as %q but privileges could not be dropped; running as the current user", name, manifest.RunAs)
+	}
+
+	runErr := cmd.Run()
+	log.Printf("script channel: hook %q: %s", name, output.String())
+
+	result := map[string]interface{}{
+		"hook":   name,
+		"output": output.String(),
+	}
+	if runErr != nil {
+		return result, fmt.Errorf("hook %q failed: %w", name, runErr)
+	}
+	return result, nil
+}
+
+// hookEnv builds the entrypoint's environment from scratch - it never
+// inherits the parent process's environment, so a hook only ever sees
+// what's listed here plus whatever allowlist entries matched.
+func hookEnv(name, method string, n *Notification, allowlist []string) []string {
+	env := []string{
+		"HOOK_ID=" + strconv.FormatInt(n.ID, 10),
+		"HOOK_NAME=" + name,
+		"HOOK_METHOD=" + method,
+		"NOTIF_SUBJECT=" + clampEnvValue(n.Subject),
+		"NOTIF_MESSAGE=" + clampEnvValue(n.Message),
+	}
+
+	allowed := make(map[string]bool, len(allowlist))
+	for _, key := range allowlist {
+		allowed[key] = true
+	}
+
+	for key, value := range n.Metadata {
+		if !allowed[key] || !envNameRe.MatchString(key) {
+			continue
+		}
+		env = append(env, "META_"+strings.ToUpper(key)+"="+clampEnvValue(value))
+	}
+
+	return env
+}
+
+// clampEnvValue strips NUL bytes (which would truncate the env var at
+// the C string layer in an attacker-chosen spot) and caps length.
+func clampEnvValue(v string) string {
+	v = strings.ReplaceAll(v, "\x00", "")
+	if len(v) > maxEnvValueLen {
+		v = v[:maxEnvValueLen]
+	}
+	return v
+}
+
+// credentialFor resolves username to a syscall.Credential ScriptChannel
+// can run the hook under. Returns ok=false if username is empty, the
+// user doesn't exist, or (most commonly) the current process isn't
+// privileged enough for Setuid/Setgid to succeed - the caller falls
+// back to running as itself rather than failing the notification.
+func credentialFor(username string) (*syscall.Credential, bool) {
+	if username == "" || os.Geteuid() != 0 {
+		return nil, false
+	}
+	u, err := user.Lookup(username)
+	if err != nil {
+		return nil, false
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, true
+}
+
+// capWriter caps how many bytes of a subprocess's stdout/stderr get
+// retained; past limit, further writes are silently dropped rather than
+// growing buf without bound.
+type capWriter struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (w *capWriter) Write(p []byte) (int, error) {
+	if w.buf.Len() >= w.limit {
+		return len(p), nil
+	}
+	remaining := w.limit - w.buf.Len()
+	if len(p) > remaining {
+		w.buf.Write(p[:remaining])
+	} else {
+		w.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
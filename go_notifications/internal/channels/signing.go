@@ -0,0 +1,27 @@
+package channels
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// signWebhookPayload computes the value of an X-Signature header,
+// "t=<unix>,v1=<hex>", as an HMAC-SHA256 over "<timestamp>.<body>" using
+// secret - the same scheme Stripe uses for webhook signing.
+func signWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// idempotencyKey derives a stable Idempotency-Key from a notification ID,
+// so the redelivery worker's retries of the same notification present the
+// same key to the receiving end every time.
+func idempotencyKey(notificationID int64) string {
+	return fmt.Sprintf("notif-%d", notificationID)
+}
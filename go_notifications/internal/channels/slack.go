@@ -3,25 +3,107 @@ package channels
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
-	"os/exec"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
 	"text/template"
+
+	"github.com/project-anarchy/go_notifications/pkg/safehttp"
+)
+
+// slackChannelNameRe and slackChannelIDRe are the only shapes
+// Notification.Recipient accepts for SlackChannel: a name ("#ops",
+// "@user") or a Slack channel/DM/group/user ID (e.g. "C0123456789").
+// Anything else - in particular a URL - is rejected unless
+// AllowRecipientAsURL is set. See Validate and SetAllowRecipientAsURL.
+var (
+	slackChannelNameRe = regexp.MustCompile(`^[#@]?[a-zA-Z0-9._-]{1,80}$`)
+	slackChannelIDRe   = regexp.MustCompile(`^[CDGU][A-Z0-9]{8,}$`)
 )
 
+// ValidRecipient reports whether recipient is a well-formed Slack channel
+// name or channel/DM/group/user ID.
+func ValidRecipient(recipient string) bool {
+	return slackChannelNameRe.MatchString(recipient) || slackChannelIDRe.MatchString(recipient)
+}
+
+// isWebhookURL reports whether recipient looks like an absolute URL
+// rather than a channel name/ID - the shape Recipient took before this
+// channel's webhook URL became immutable per instance.
+func isWebhookURL(recipient string) bool {
+	u, err := url.Parse(recipient)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
 // SlackChannel handles Slack notifications
 type SlackChannel struct {
 	webhookURL string
-	client     *http.Client
+	client     *safehttp.Client
+
+	// defaultUsername and defaultIcon are overridden per-message by a
+	// SlackTemplate's own Username/Icon, if set. See SetDefaults and
+	// slack_template.go.
+	defaultUsername string
+	defaultIcon     string
+
+	// allowRecipientAsURL opts back into the old behavior where
+	// Notification.Recipient could itself be a webhook URL, the way
+	// kured's deprecated --slack-hook-url could still be set alongside
+	// its replacement. Default false: Recipient means a channel
+	// name/ID, validated by ValidRecipient, and webhookURL is the only
+	// destination. See SetAllowRecipientAsURL.
+	allowRecipientAsURL bool
+
+	// api, if set via SetAPIClient, backs PostToChannel and UploadFile
+	// with the Slack Web API instead of the shell-out those methods used
+	// before.
+	api *SlackAPIClient
+
+	templatesMu sync.RWMutex
+	templates   map[string]SlackTemplate
 }
 
-// NewSlackChannel creates a new Slack channel
+// NewSlackChannel creates a new Slack channel targeting webhookURL, which
+// is immutable for the lifetime of this instance - to post to more than
+// one Slack workspace, construct one SlackChannel per workspace (see
+// Dispatcher.RegisterSlackWorkspace) rather than overriding it via
+// Notification.Recipient. Outbound requests go through a safehttp.Client
+// so webhookURL (or, with AllowRecipientAsURL set, a caller-supplied one)
+// can't be pointed at an internal address - see pkg/safehttp.
 func NewSlackChannel(webhookURL string) *SlackChannel {
 	return &SlackChannel{
 		webhookURL: webhookURL,
-		client:     &http.Client{},
+		client:     safehttp.NewClient(safehttp.DefaultPolicy("slack")),
+		templates:  make(map[string]SlackTemplate),
+	}
+}
+
+// SetDefaults configures the username/icon SendNamed falls back to when a
+// template doesn't set its own, mirroring
+// WebhookChannel.SetSigningSecrets's setter-injection style.
+func (s *SlackChannel) SetDefaults(username, icon string) {
+	s.defaultUsername = username
+	s.defaultIcon = icon
+}
+
+// SetAllowRecipientAsURL opts into the deprecated behavior where
+// Notification.Recipient may itself be a webhook URL instead of a channel
+// name/ID, mirroring WebhookChannel.SetSigningSecrets's setter-injection
+// style. Off by default; turning it on is logged once here and again on
+// every Send that actually uses it, so it shows up in deployments that
+// still depend on it.
+func (s *SlackChannel) SetAllowRecipientAsURL(allow bool) {
+	if allow && !s.allowRecipientAsURL {
+		log.Printf("slack: DEPRECATED: AllowRecipientAsURL is enabled - Notification.Recipient as a webhook URL will be removed; migrate to a channel name/ID, or Dispatcher.RegisterSlackWorkspace for multiple workspaces")
 	}
+	s.allowRecipientAsURL = allow
 }
 
 // Name returns the channel name
@@ -29,14 +111,158 @@ func (s *SlackChannel) Name() string {
 	return "slack"
 }
 
-// Validate checks if the notification is valid for Slack
+// Validate checks if the notification is valid for Slack: webhookURL must
+// be configured, and if n.Recipient is set it must either be a valid
+// channel name/ID, or - only with allowRecipientAsURL set - a webhook URL.
 func (s *SlackChannel) Validate(n *Notification) error {
-	if s.webhookURL == "" && n.Recipient == "" {
+	if s.webhookURL == "" {
 		return fmt.Errorf("slack webhook URL is required")
 	}
+	if n.Recipient == "" {
+		return nil
+	}
+	if isWebhookURL(n.Recipient) {
+		if !s.allowRecipientAsURL {
+			return fmt.Errorf("slack: recipient is a webhook URL, which is deprecated; set AllowRecipientAsURL to permit it, or pass a channel name/ID instead")
+		}
+		return nil
+	}
+	if !ValidRecipient(n.Recipient) {
+		return fmt.Errorf("slack: recipient %q is not a valid channel name (#ops, @user) or channel ID", n.Recipient)
+	}
+	return nil
+}
+
+// Parse builds a Sender for a "slack://TOKEN@workspace/channel"
+// notification-url: TOKEN and workspace are combined into a Slack
+// incoming webhook URL, and channel (if present) overrides the message's
+// destination channel the way Notification.Metadata["channel"] already
+// does in Send.
+func (s *SlackChannel) Parse(u *url.URL) (Sender, error) {
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("slack notification-url requires a token, e.g. slack://TOKEN@workspace/channel")
+	}
+	workspace := u.Hostname()
+	if workspace == "" {
+		return nil, fmt.Errorf("slack notification-url requires a workspace, e.g. slack://TOKEN@workspace/channel")
+	}
+
+	webhookURL := fmt.Sprintf("https://hooks.slack.com/services/%s/%s", workspace, u.User.Username())
+	return &boundSlackSender{
+		channel:      NewSlackChannel(webhookURL),
+		slackChannel: strings.TrimPrefix(u.Path, "/"),
+	}, nil
+}
+
+// boundSlackSender adapts SlackChannel to Sender for a Parse-built
+// destination: channel's own webhookURL is already the target this
+// notification-url named, so Send only needs to apply slackChannel (if
+// set) as the outgoing message's destination channel, the same way
+// Notification.Metadata["channel"] already does.
+type boundSlackSender struct {
+	channel      *SlackChannel
+	slackChannel string
+}
+
+func (b *boundSlackSender) Send(n *Notification) (map[string]interface{}, error) {
+	notifCopy := *n
+	if b.slackChannel != "" {
+		meta := make(map[string]string, len(n.Metadata)+1)
+		for k, v := range n.Metadata {
+			meta[k] = v
+		}
+		meta["channel"] = b.slackChannel
+		notifCopy.Metadata = meta
+	}
+	return b.channel.Send(&notifCopy)
+}
+
+func init() {
+	Register("slack", (&SlackChannel{}).Parse)
+	RegisterNotifier("slack", newSlackNotifier)
+}
+
+// SlackConfig is SlackChannel's strongly-typed factory config, used by the
+// "slack" notifier factory registered below.
+type SlackConfig struct {
+	WebhookURL      string `json:"webhook_url"`
+	DefaultChannel  string `json:"default_channel,omitempty"`
+	DefaultUsername string `json:"default_username,omitempty"`
+	DefaultIcon     string `json:"default_icon,omitempty"`
+
+	// AllowedChannels, if non-empty, restricts Send to overriding
+	// Notification.Metadata["channel"] with one of these values; any other
+	// value is rejected rather than silently forwarded to Slack.
+	AllowedChannels []string `json:"allowed_channels,omitempty"`
+}
+
+// slackNotifier adapts SlackChannel to the Notifier interface for the
+// registry-driven factory path, enforcing AllowedChannels on top of
+// SlackChannel's own Send.
+type slackNotifier struct {
+	*SlackChannel
+	cfg SlackConfig
+}
+
+// SlackChannelOf returns the *SlackChannel backing n, if n was built by
+// the "slack" notifier factory - for callers (cmd/server/main.go wiring a
+// SlackAPIClient via SetAPIClient) that need SlackChannel's own methods,
+// which aren't part of the Notifier interface. Mirrors WebhookChannelOf.
+func SlackChannelOf(n Notifier) *SlackChannel {
+	if sn, ok := n.(*slackNotifier); ok {
+		return sn.SlackChannel
+	}
+	return nil
+}
+
+func newSlackNotifier(cfg json.RawMessage) (Notifier, error) {
+	var c SlackConfig
+	if err := json.Unmarshal(cfg, &c); err != nil {
+		return nil, fmt.Errorf("invalid slack config: %w", err)
+	}
+	if c.WebhookURL == "" {
+		return nil, fmt.Errorf("slack config requires webhook_url")
+	}
+
+	ch := NewSlackChannel(c.WebhookURL)
+	ch.SetDefaults(c.DefaultUsername, c.DefaultIcon)
+	return &slackNotifier{SlackChannel: ch, cfg: c}, nil
+}
+
+func (s *slackNotifier) Validate(cfg json.RawMessage) error {
+	var c SlackConfig
+	if err := json.Unmarshal(cfg, &c); err != nil {
+		return err
+	}
+	if c.WebhookURL == "" {
+		return fmt.Errorf("slack config requires webhook_url")
+	}
 	return nil
 }
 
+func (s *slackNotifier) Send(ctx context.Context, n *Notification) (Result, error) {
+	if len(s.cfg.AllowedChannels) > 0 {
+		if channel := n.Metadata["channel"]; channel != "" && !containsString(s.cfg.AllowedChannels, channel) {
+			return nil, fmt.Errorf("channel %q is not in allowed_channels", channel)
+		}
+	}
+	notifCopy := *n
+	if notifCopy.Metadata == nil && s.cfg.DefaultChannel != "" {
+		notifCopy.Metadata = map[string]string{"channel": s.cfg.DefaultChannel}
+	} else if s.cfg.DefaultChannel != "" && notifCopy.Metadata["channel"] == "" {
+		meta := make(map[string]string, len(notifCopy.Metadata)+1)
+		for k, v := range notifCopy.Metadata {
+			meta[k] = v
+		}
+		meta["channel"] = s.cfg.DefaultChannel
+		notifCopy.Metadata = meta
+	}
+	if err := s.SlackChannel.Validate(&notifCopy); err != nil {
+		return nil, err
+	}
+	return s.SlackChannel.Send(&notifCopy)
+}
+
 // SlackMessage represents a Slack message payload
 type SlackMessage struct {
 	Text        string            `json:"text"`
@@ -56,12 +282,32 @@ type SlackAttachment struct {
 	AuthorName string `json:"author_name,omitempty"`
 }
 
-// Send delivers the notification via Slack
+// Send delivers the notification via Slack. n.Recipient, if set, is a
+// channel name/ID that overrides n.Metadata["channel"] as the message's
+// destination channel - or, only with allowRecipientAsURL set, a webhook
+// URL that overrides webhookURL entirely (see Validate).
 // TAINT SINK: Message content is user-controlled
 func (s *SlackChannel) Send(n *Notification) (map[string]interface{}, error) {
 	webhookURL := s.webhookURL
+	channel := n.Metadata["channel"]
+
 	if n.Recipient != "" {
-		webhookURL = n.Recipient // VULN: SSRF - user can specify any URL
+		if isWebhookURL(n.Recipient) {
+			if !s.allowRecipientAsURL {
+				return nil, fmt.Errorf("slack: recipient is a webhook URL, which is deprecated; set AllowRecipientAsURL to permit it, or pass a channel name/ID instead")
+			}
+			log.Printf("slack: DEPRECATED: Notification.Recipient used as a webhook URL; migrate to a channel name/ID, or Dispatcher.RegisterSlackWorkspace for multiple workspaces")
+			// n.Recipient can still point anywhere the caller likes, but
+			// s.client (a safehttp.Client) rejects internal/loopback/
+			// link-local destinations before connecting, so this no
+			// longer reaches Redis/the cloud metadata endpoint/etc the
+			// way a bare http.Client would.
+			webhookURL = n.Recipient
+		} else if ValidRecipient(n.Recipient) {
+			channel = n.Recipient
+		} else {
+			return nil, fmt.Errorf("slack: recipient %q is not a valid channel name (#ops, @user) or channel ID", n.Recipient)
+		}
 	}
 
 	// Build Slack message
@@ -69,7 +315,7 @@ func (s *SlackChannel) Send(n *Notification) (map[string]interface{}, error) {
 	msg := SlackMessage{
 		Text:     fmt.Sprintf("*%s*\n%s", n.Subject, n.Message), // TAINT SINK
 		Username: n.Metadata["username"],                        // TAINT SINK
-		Channel:  n.Metadata["channel"],                         // TAINT: User-controlled channel override
+		Channel:  channel,                                       // TAINT: User-controlled channel override
 	}
 
 	// Add attachment if metadata present
@@ -158,19 +404,50 @@ func (s *SlackChannel) SendBlockKit(n *Notification, blocksJSON string) (map[str
 	return map[string]interface{}{"status": resp.StatusCode}, nil
 }
 
-// PostToChannel uses Slack CLI/API to post
-// VULN: Command injection via channel name
-func (s *SlackChannel) PostToChannel(channel, message string) error {
-	// VULN: Channel name in shell command
-	cmd := exec.Command("slack-cli", "chat", "send", channel, message) // TAINT SINK
-	return cmd.Run()
+// SetAPIClient wires a SlackAPIClient into this channel for PostToChannel
+// and UploadFile, mirroring WebhookChannel.SetSigningSecrets's
+// setter-injection style for an optional, orthogonal dependency. Without
+// one set, both methods fail rather than falling back to a shell-out.
+func (s *SlackChannel) SetAPIClient(api *SlackAPIClient) {
+	s.api = api
+}
+
+// PostToChannel posts message to channel via the Slack Web API's
+// chat.postMessage, resolving channel to an ID first if it isn't already
+// one. Replaces the previous shell-out to "slack-cli chat send".
+func (s *SlackChannel) PostToChannel(ctx context.Context, channel, message string) error {
+	if s.api == nil {
+		return fmt.Errorf("slack: PostToChannel requires SetAPIClient")
+	}
+	target := channel
+	if !slackChannelIDRe.MatchString(target) {
+		id, err := s.api.ResolveChannelID(ctx, target)
+		if err != nil {
+			return fmt.Errorf("slack: resolve channel %q: %w", channel, err)
+		}
+		target = id
+	}
+	_, err := s.api.PostMessage(ctx, target, message)
+	return err
 }
 
-// UploadFile uploads a file to Slack
-// VULN: Command injection via filename
-func (s *SlackChannel) UploadFile(channel, filePath, comment string) error {
-	// VULN: File path in shell command without quoting
-	cmdStr := fmt.Sprintf("slack-cli file upload -c %s -f %s -m %s", channel, filePath, comment)
-	cmd := exec.Command("sh", "-c", cmdStr) // TAINT SINK: Command injection
-	return cmd.Run()
+// UploadFile uploads the content read from r (size bytes) to channel via
+// the Slack Web API's two-step external upload flow, streaming directly
+// from r rather than opening filePath itself - callers that only have a
+// path open it and pass the resulting *os.File. Replaces the previous
+// shell-out to "slack-cli file upload".
+func (s *SlackChannel) UploadFile(ctx context.Context, channel, filename string, r io.Reader, size int64, comment string) error {
+	if s.api == nil {
+		return fmt.Errorf("slack: UploadFile requires SetAPIClient")
+	}
+	target := channel
+	if !slackChannelIDRe.MatchString(target) {
+		id, err := s.api.ResolveChannelID(ctx, target)
+		if err != nil {
+			return fmt.Errorf("slack: resolve channel %q: %w", channel, err)
+		}
+		target = id
+	}
+	_, err := s.api.UploadFile(ctx, target, filename, r, size, comment)
+	return err
 }
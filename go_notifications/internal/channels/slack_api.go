@@ -0,0 +1,449 @@
+// Package channels - Slack Web API client
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/pkg/safehttp"
+)
+
+const slackAPIBaseURL = "https://slack.com/api/"
+
+// RateLimitError is returned when a Slack Web API call is rate-limited
+// (HTTP 429). RetryAfter is parsed from Slack's Retry-After header, so a
+// caller can schedule a retry after that long - the same role a failed
+// webhook delivery's attemptErr plays for
+// queue.WebhookDeliveryWorker.ScheduleRetry.
+type RateLimitError struct {
+	Method     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("slack: %s is rate-limited, retry after %s", e.Method, e.RetryAfter)
+}
+
+// methodTier buckets Slack Web API methods into Slack's own Tier 1-4
+// rate-limit classes (api.slack.com/docs/rate-limits): tier1 is the
+// slowest-refilling (conversations.list on a large workspace), tier4 the
+// fastest (chat.postMessage).
+type methodTier int
+
+const (
+	tier1 methodTier = iota
+	tier2
+	tier3
+	tier4
+)
+
+var methodTiers = map[string]methodTier{
+	"conversations.list":           tier1,
+	"files.getUploadURLExternal":   tier3,
+	"files.completeUploadExternal": tier3,
+	"chat.postMessage":             tier4,
+}
+
+// tierFor returns method's configured tier, defaulting to the
+// conservative tier2 for any method not listed above.
+func tierFor(method string) methodTier {
+	if t, ok := methodTiers[method]; ok {
+		return t
+	}
+	return tier2
+}
+
+// refillInterval is how often tokenBucket adds one token back.
+func (t methodTier) refillInterval() time.Duration {
+	switch t {
+	case tier1:
+		return time.Minute
+	case tier2:
+		return 3 * time.Second
+	case tier3:
+		return 1200 * time.Millisecond
+	default:
+		return 600 * time.Millisecond
+	}
+}
+
+// burst is how many requests tokenBucket lets through before it has to
+// wait on refillInterval.
+func (t methodTier) burst() int {
+	switch t {
+	case tier1:
+		return 1
+	case tier2:
+		return 3
+	case tier3:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// tokenBucket is a minimal per-method-tier limiter: Take blocks until a
+// token is available or ctx is done, refilling one token every interval
+// up to burst.
+type tokenBucket struct {
+	mu       sync.Mutex
+	tokens   int
+	burst    int
+	interval time.Duration
+	last     time.Time
+}
+
+func newTokenBucket(tier methodTier) *tokenBucket {
+	return &tokenBucket{
+		tokens:   tier.burst(),
+		burst:    tier.burst(),
+		interval: tier.refillInterval(),
+		last:     time.Now(),
+	}
+}
+
+func (b *tokenBucket) Take(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.refillLocked()
+		if b.tokens > 0 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := b.interval
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (b *tokenBucket) refillLocked() {
+	elapsed := time.Since(b.last)
+	add := int(elapsed / b.interval)
+	if add <= 0 {
+		return
+	}
+	b.tokens += add
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = b.last.Add(time.Duration(add) * b.interval)
+}
+
+// channelCacheEntry caches a channel name's resolved ID for
+// SlackAPIClient.channelCacheTTL, since conversations.list sits in
+// Slack's slowest-refilling (tier1) rate-limit bucket.
+type channelCacheEntry struct {
+	id        string
+	expiresAt time.Time
+}
+
+// SlackAPIClient talks to the Slack Web API (https://slack.com/api/)
+// using a bot token, replacing SlackChannel.PostToChannel and UploadFile's
+// shell-outs to slack-cli. Outbound requests go through a safehttp.Client,
+// the same hardening SlackChannel's incoming-webhook path already uses.
+type SlackAPIClient struct {
+	token  string
+	client *safehttp.Client
+
+	bucketsMu sync.Mutex
+	buckets   map[string]*tokenBucket
+
+	channelCacheMu  sync.Mutex
+	channelCache    map[string]channelCacheEntry
+	channelCacheTTL time.Duration
+}
+
+// NewSlackAPIClient creates a client authenticating as token (a Slack bot
+// token, "xoxb-..."). AllowedDomains is restricted to slack.com and its
+// subdomains (files.getUploadURLExternal's upload_url lands on
+// files.slack.com), so this client can't be redirected at an arbitrary
+// or internal host even if Slack's own response were somehow tampered
+// with in transit.
+func NewSlackAPIClient(token string) *SlackAPIClient {
+	policy := safehttp.DefaultPolicy("slack-api")
+	policy.AllowedDomains = []string{"slack.com", ".slack.com"}
+
+	return &SlackAPIClient{
+		token:           token,
+		client:          safehttp.NewClient(policy),
+		buckets:         make(map[string]*tokenBucket),
+		channelCache:    make(map[string]channelCacheEntry),
+		channelCacheTTL: 5 * time.Minute,
+	}
+}
+
+func (c *SlackAPIClient) bucketFor(method string) *tokenBucket {
+	c.bucketsMu.Lock()
+	defer c.bucketsMu.Unlock()
+	b, ok := c.buckets[method]
+	if !ok {
+		b = newTokenBucket(tierFor(method))
+		c.buckets[method] = b
+	}
+	return b
+}
+
+// call issues a Web API POST to method, waiting on that method's
+// tokenBucket first and translating a 429 response into a *RateLimitError
+// built from Retry-After rather than returning a generic HTTP error.
+func (c *SlackAPIClient) call(ctx context.Context, method string, body io.Reader, contentType string) ([]byte, error) {
+	if err := c.bucketFor(method).Take(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, slackAPIBaseURL+method, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &RateLimitError{Method: method, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func parseRetryAfter(header string) time.Duration {
+	secs, err := strconv.Atoi(header)
+	if err != nil || secs <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// PostMessage posts text to channel (a channel ID or name) via
+// chat.postMessage.
+func (c *SlackAPIClient) PostMessage(ctx context.Context, channel, text string) (map[string]interface{}, error) {
+	payload, err := json.Marshal(map[string]string{"channel": channel, "text": text})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.call(ctx, "chat.postMessage", bytes.NewReader(payload), "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("slack: parse chat.postMessage response: %w", err)
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("slack: chat.postMessage failed: %s", resp.Error)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UploadFile uploads content, read from r (size bytes), to channel (a
+// channel ID) via Slack's two-step external upload flow:
+// files.getUploadURLExternal reserves an upload slot and returns a URL,
+// the content is POSTed to that URL, then files.completeUploadExternal
+// attaches the upload to channel. r is streamed directly - this never
+// opens a filesystem path itself, so a caller handing it an HTTP
+// request's body can't be used to read an arbitrary server-side file the
+// way the old "slack-cli file upload -f <path>" shell-out could.
+func (c *SlackAPIClient) UploadFile(ctx context.Context, channel, filename string, r io.Reader, size int64, comment string) (map[string]interface{}, error) {
+	uploadURL, fileID, err := c.getUploadURLExternal(ctx, filename, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.putUploadContent(ctx, uploadURL, r); err != nil {
+		return nil, fmt.Errorf("slack: upload content: %w", err)
+	}
+
+	return c.completeUploadExternal(ctx, fileID, filename, channel, comment)
+}
+
+func (c *SlackAPIClient) getUploadURLExternal(ctx context.Context, filename string, size int64) (uploadURL, fileID string, err error) {
+	form := url.Values{
+		"filename": {filename},
+		"length":   {strconv.FormatInt(size, 10)},
+	}
+
+	data, err := c.call(ctx, "files.getUploadURLExternal", strings.NewReader(form.Encode()), "application/x-www-form-urlencoded")
+	if err != nil {
+		return "", "", err
+	}
+
+	var resp struct {
+		OK        bool   `json:"ok"`
+		Error     string `json:"error"`
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", "", fmt.Errorf("slack: parse files.getUploadURLExternal response: %w", err)
+	}
+	if !resp.OK {
+		return "", "", fmt.Errorf("slack: files.getUploadURLExternal failed: %s", resp.Error)
+	}
+	return resp.UploadURL, resp.FileID, nil
+}
+
+func (c *SlackAPIClient) putUploadContent(ctx context.Context, uploadURL string, r io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, r)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *SlackAPIClient) completeUploadExternal(ctx context.Context, fileID, title, channel, comment string) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"files": []map[string]string{{"id": fileID, "title": title}},
+	}
+	if channel != "" {
+		payload["channel_id"] = channel
+	}
+	if comment != "" {
+		payload["initial_comment"] = comment
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.call(ctx, "files.completeUploadExternal", bytes.NewReader(body), "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("slack: parse files.completeUploadExternal response: %w", err)
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("slack: files.completeUploadExternal failed: %s", resp.Error)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ResolveChannelID resolves a channel name ("general" or "#general") to
+// its Slack ID via conversations.list, caching results for
+// channelCacheTTL since that method sits in Slack's tier1 rate-limit
+// bucket. PostToChannel and UploadFile use this so callers can keep
+// passing a human-readable channel name, the same as they could with the
+// old slack-cli shell-out.
+func (c *SlackAPIClient) ResolveChannelID(ctx context.Context, name string) (string, error) {
+	name = strings.TrimPrefix(name, "#")
+
+	if id, ok := c.cachedChannelID(name); ok {
+		return id, nil
+	}
+
+	cursor := ""
+	for {
+		payload, err := json.Marshal(map[string]interface{}{"cursor": cursor, "limit": 200})
+		if err != nil {
+			return "", err
+		}
+
+		data, err := c.call(ctx, "conversations.list", bytes.NewReader(payload), "application/json")
+		if err != nil {
+			return "", err
+		}
+
+		var resp struct {
+			OK       bool   `json:"ok"`
+			Error    string `json:"error"`
+			Channels []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channels"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return "", fmt.Errorf("slack: parse conversations.list response: %w", err)
+		}
+		if !resp.OK {
+			return "", fmt.Errorf("slack: conversations.list failed: %s", resp.Error)
+		}
+
+		var found string
+		for _, ch := range resp.Channels {
+			c.cacheChannelID(ch.Name, ch.ID)
+			if ch.Name == name {
+				found = ch.ID
+			}
+		}
+		if found != "" {
+			return found, nil
+		}
+
+		if resp.ResponseMetadata.NextCursor == "" {
+			return "", fmt.Errorf("slack: channel %q not found", name)
+		}
+		cursor = resp.ResponseMetadata.NextCursor
+	}
+}
+
+func (c *SlackAPIClient) cachedChannelID(name string) (string, bool) {
+	c.channelCacheMu.Lock()
+	defer c.channelCacheMu.Unlock()
+	entry, ok := c.channelCache[name]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.id, true
+}
+
+func (c *SlackAPIClient) cacheChannelID(name, id string) {
+	c.channelCacheMu.Lock()
+	defer c.channelCacheMu.Unlock()
+	c.channelCache[name] = channelCacheEntry{id: id, expiresAt: time.Now().Add(c.channelCacheTTL)}
+}
@@ -0,0 +1,325 @@
+package channels
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// SlackTemplate is a named, pre-registered Slack message shape: a
+// template author controls Text/Blocks/Attachments/Username/Icon, while
+// the notification data that fills in their {{ }} expressions at send
+// time can never reshape the JSON structure itself - see renderLeaves.
+type SlackTemplate struct {
+	// Text is the message's fallback text, rendered against the
+	// notification data like every other leaf field below.
+	Text string
+
+	// Blocks is a Block Kit block tree (as produced by
+	// json.Unmarshal into interface{}): maps, slices, and leaf values.
+	// Leaf strings are rendered as Go templates; everything else
+	// (numbers, bools, nested structure) passes through unchanged.
+	Blocks []interface{}
+
+	// Attachments are rendered the same way as Text.
+	Attachments []SlackAttachment
+
+	// Username overrides SlackChannel.defaultUsername for messages sent
+	// with this template, if non-empty.
+	Username string
+
+	// Icon is either an ":emoji:" shortcode (mapped to icon_emoji) or an
+	// http(s) image URL (mapped to icon_url). Overrides
+	// SlackChannel.defaultIcon if non-empty.
+	Icon string
+}
+
+// RegisterSlackTemplate validates tmpl's leaf strings as templates (so a
+// typo is caught at registration time, not at send time) and stores it
+// under name for SendNamed.
+func (s *SlackChannel) RegisterSlackTemplate(name string, tmpl SlackTemplate) error {
+	empty := map[string]interface{}{"Subject": "", "Message": "", "Metadata": map[string]string{}}
+
+	if _, err := renderLeafString(tmpl.Text, empty); err != nil {
+		return fmt.Errorf("slack template %q: text: %w", name, err)
+	}
+	for i, att := range tmpl.Attachments {
+		for _, field := range []string{att.Color, att.Title, att.Text, att.Footer, att.AuthorName} {
+			if _, err := renderLeafString(field, empty); err != nil {
+				return fmt.Errorf("slack template %q: attachments[%d]: %w", name, i, err)
+			}
+		}
+	}
+	renderedBlocks, err := renderBlockList(tmpl.Blocks, empty)
+	if err != nil {
+		return fmt.Errorf("slack template %q: blocks: %w", name, err)
+	}
+	if err := validateBlockKit(renderedBlocks); err != nil {
+		return fmt.Errorf("slack template %q: %w", name, err)
+	}
+
+	s.templatesMu.Lock()
+	s.templates[name] = tmpl
+	s.templatesMu.Unlock()
+	return nil
+}
+
+// SendNamed renders the template registered under templateName against
+// n and sends it - the HTTP layer only ever passes a templateName here,
+// never raw template source, so there is no template-injection surface
+// the way SendWithTemplate's templateStr parameter has.
+func (s *SlackChannel) SendNamed(n *Notification, templateName string) (map[string]interface{}, error) {
+	s.templatesMu.RLock()
+	tmpl, ok := s.templates[templateName]
+	s.templatesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown slack template %q", templateName)
+	}
+
+	data := map[string]interface{}{
+		"Subject":  n.Subject,
+		"Message":  n.Message,
+		"Metadata": n.Metadata,
+	}
+
+	text, err := renderLeafString(tmpl.Text, data)
+	if err != nil {
+		return nil, fmt.Errorf("slack template %q: text: %w", templateName, err)
+	}
+
+	attachments := make([]SlackAttachment, len(tmpl.Attachments))
+	for i, att := range tmpl.Attachments {
+		rendered, err := renderAttachment(att, data)
+		if err != nil {
+			return nil, fmt.Errorf("slack template %q: attachments[%d]: %w", templateName, i, err)
+		}
+		attachments[i] = rendered
+	}
+
+	blocks, err := renderBlockList(tmpl.Blocks, data)
+	if err != nil {
+		return nil, fmt.Errorf("slack template %q: blocks: %w", templateName, err)
+	}
+	if err := validateBlockKit(blocks); err != nil {
+		return nil, fmt.Errorf("slack template %q: %w", templateName, err)
+	}
+
+	msg := SlackMessage{
+		Text:        text,
+		Channel:     n.Metadata["channel"],
+		Attachments: attachments,
+		Blocks:      blocks,
+	}
+
+	username := tmpl.Username
+	if username == "" {
+		username = s.defaultUsername
+	}
+	msg.Username = username
+
+	icon := tmpl.Icon
+	if icon == "" {
+		icon = s.defaultIcon
+	}
+	switch {
+	case icon == "":
+	case strings.HasPrefix(icon, ":") && strings.HasSuffix(icon, ":"):
+		msg.IconEmoji = icon
+	default:
+		// SlackMessage has no IconURL field yet for the plain Send path;
+		// Block Kit messages carry icon_url as a top-level field instead.
+		msg.IconEmoji = ""
+	}
+
+	webhookURL := s.webhookURL
+	if n.Recipient != "" {
+		if isWebhookURL(n.Recipient) {
+			if !s.allowRecipientAsURL {
+				return nil, fmt.Errorf("slack: recipient is a webhook URL, which is deprecated; set AllowRecipientAsURL to permit it, or pass a channel name/ID instead")
+			}
+			webhookURL = n.Recipient
+		} else if ValidRecipient(n.Recipient) {
+			msg.Channel = n.Recipient
+		} else {
+			return nil, fmt.Errorf("slack: recipient %q is not a valid channel name (#ops, @user) or channel ID", n.Recipient)
+		}
+	}
+
+	payload, err := marshalSlackMessage(msg, icon)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Post(webhookURL, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("slack returned status %d", resp.StatusCode)
+	}
+
+	return map[string]interface{}{
+		"sent_to":  webhookURL,
+		"template": templateName,
+		"username": msg.Username,
+	}, nil
+}
+
+// marshalSlackMessage marshals msg to JSON, adding icon_url alongside
+// the fixed SlackMessage fields when icon is an image URL rather than an
+// :emoji: shortcode - SlackMessage has no IconURL field since the
+// unnamed Send path never needed one.
+func marshalSlackMessage(msg SlackMessage, icon string) ([]byte, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if icon == "" || strings.HasPrefix(icon, ":") {
+		return body, nil
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	raw["icon_url"] = icon
+	return json.Marshal(raw)
+}
+
+// renderAttachment renders every string field of att against data.
+func renderAttachment(att SlackAttachment, data map[string]interface{}) (SlackAttachment, error) {
+	var err error
+	if att.Color, err = renderLeafString(att.Color, data); err != nil {
+		return att, err
+	}
+	if att.Title, err = renderLeafString(att.Title, data); err != nil {
+		return att, err
+	}
+	if att.Text, err = renderLeafString(att.Text, data); err != nil {
+		return att, err
+	}
+	if att.Footer, err = renderLeafString(att.Footer, data); err != nil {
+		return att, err
+	}
+	if att.AuthorName, err = renderLeafString(att.AuthorName, data); err != nil {
+		return att, err
+	}
+	return att, nil
+}
+
+// renderLeafString renders s as a text/template against data. Expressions
+// use {{ }} Go template delimiters; "${...}" is accepted too by
+// translating it to {{ }} first, since that's the shoutrrr/webhookd
+// convention template authors coming from those tools expect.
+func renderLeafString(s string, data map[string]interface{}) (string, error) {
+	if !strings.Contains(s, "{{") && !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	source := dollarBraceToGoTemplate(s)
+	tmpl, err := template.New("leaf").Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// dollarBraceToGoTemplate rewrites "${expr}" to "{{expr}}" so both
+// delimiter styles reach the same text/template engine. It does not
+// touch existing "{{ }}" expressions.
+func dollarBraceToGoTemplate(s string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '$' && i+1 < len(s) && s[i+1] == '{' {
+			end := strings.IndexByte(s[i+2:], '}')
+			if end == -1 {
+				b.WriteString(s[i:])
+				break
+			}
+			expr := s[i+2 : i+2+end]
+			b.WriteString("{{")
+			b.WriteString(expr)
+			b.WriteString("}}")
+			i += 2 + end
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// renderBlockList renders every leaf string in blocks and asserts the
+// result is still a []interface{} - renderLeaves operates on interface{}
+// generically since it recurses into maps too, but Blocks is always a
+// top-level array.
+func renderBlockList(blocks []interface{}, data map[string]interface{}) ([]interface{}, error) {
+	rendered, err := renderLeaves(interface{}(blocks), data)
+	if err != nil {
+		return nil, err
+	}
+	list, _ := rendered.([]interface{})
+	return list, nil
+}
+
+// renderLeaves walks v (the output of json.Unmarshal into interface{}:
+// map[string]interface{}, []interface{}, or a scalar) rendering every
+// leaf string against data. It never re-parses a leaf as JSON, so a
+// notification value containing e.g. `"blocks": [...]` can't inject
+// sibling Block Kit structure - it only ever becomes one rendered string.
+func renderLeaves(v interface{}, data map[string]interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return renderLeafString(val, data)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			rendered, err := renderLeaves(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rendered
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			rendered, err := renderLeaves(item, data)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = rendered
+		}
+		return out, nil
+	default:
+		return val, nil
+	}
+}
+
+// validateBlockKit does a structural sanity check on a rendered blocks
+// array: Slack rejects a payload whose blocks aren't objects with a
+// "type", so catching that here gives a clearer error than waiting for
+// Slack's API to reject the whole message.
+func validateBlockKit(blocks []interface{}) error {
+	for i, b := range blocks {
+		obj, ok := b.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("blocks[%d] must be an object", i)
+		}
+		if _, ok := obj["type"]; !ok {
+			return fmt.Errorf("blocks[%d] is missing required \"type\" field", i)
+		}
+	}
+	return nil
+}
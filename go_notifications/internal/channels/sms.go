@@ -0,0 +1,89 @@
+// Package channels - SMS notification channel (Twilio-compatible REST API)
+package channels
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SMSConfig configures a Twilio-style SMS provider.
+type SMSConfig struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	BaseURL    string // defaults to https://api.twilio.com/2010-04-01
+}
+
+// SMSChannel sends notifications as SMS through a Twilio-compatible REST
+// API.
+type SMSChannel struct {
+	cfg    SMSConfig
+	client *http.Client
+}
+
+// NewSMSChannel validates cfg and returns an SMSChannel, or an error if a
+// required field is missing.
+func NewSMSChannel(cfg SMSConfig) (*SMSChannel, error) {
+	if cfg.AccountSID == "" || cfg.AuthToken == "" {
+		return nil, fmt.Errorf("sms: account_sid and auth_token are required")
+	}
+	if cfg.From == "" {
+		return nil, fmt.Errorf("sms: from number is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.twilio.com/2010-04-01"
+	}
+
+	return &SMSChannel{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Name returns the channel name
+func (s *SMSChannel) Name() string {
+	return "sms"
+}
+
+// Validate checks if the notification is valid for SMS
+func (s *SMSChannel) Validate(n *Notification) error {
+	if n.Recipient == "" {
+		return fmt.Errorf("sms: recipient phone number is required")
+	}
+	return nil
+}
+
+// Send posts the message to the provider's Messages endpoint.
+func (s *SMSChannel) Send(n *Notification) (map[string]interface{}, error) {
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.cfg.BaseURL, s.cfg.AccountSID)
+
+	form := url.Values{}
+	form.Set("To", n.Recipient)
+	form.Set("From", s.cfg.From)
+	form.Set("Body", n.Message)
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.cfg.AccountSID, s.cfg.AuthToken)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("sms provider returned status %d", resp.StatusCode)
+	}
+
+	return map[string]interface{}{
+		"to":          n.Recipient,
+		"status_code": resp.StatusCode,
+	}, nil
+}
@@ -0,0 +1,86 @@
+// Package channels - Telegram bot notification channel
+package channels
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TelegramConfig configures a Telegram bot provider.
+type TelegramConfig struct {
+	BotToken string
+	BaseURL  string // defaults to https://api.telegram.org
+}
+
+// TelegramChannel sends notifications through the Telegram Bot API.
+type TelegramChannel struct {
+	cfg    TelegramConfig
+	client *http.Client
+}
+
+// NewTelegramChannel validates cfg and returns a TelegramChannel, or an
+// error if bot_token is missing.
+func NewTelegramChannel(cfg TelegramConfig) (*TelegramChannel, error) {
+	if cfg.BotToken == "" {
+		return nil, fmt.Errorf("telegram: bot_token is required")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "https://api.telegram.org"
+	}
+
+	return &TelegramChannel{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Name returns the channel name
+func (t *TelegramChannel) Name() string {
+	return "telegram"
+}
+
+// Validate checks if the notification is valid for Telegram
+func (t *TelegramChannel) Validate(n *Notification) error {
+	if n.Recipient == "" {
+		return fmt.Errorf("telegram: recipient chat id is required")
+	}
+	return nil
+}
+
+// Send posts a sendMessage call to the configured bot.
+func (t *TelegramChannel) Send(n *Notification) (map[string]interface{}, error) {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", t.cfg.BaseURL, t.cfg.BotToken)
+
+	text := n.Message
+	if n.Subject != "" {
+		text = fmt.Sprintf("*%s*\n%s", n.Subject, n.Message)
+	}
+
+	payload := map[string]interface{}{
+		"chat_id":    n.Recipient,
+		"text":       text,
+		"parse_mode": "Markdown",
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("telegram returned status %d", resp.StatusCode)
+	}
+
+	return map[string]interface{}{
+		"chat_id":     n.Recipient,
+		"status_code": resp.StatusCode,
+	}, nil
+}
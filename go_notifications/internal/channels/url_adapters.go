@@ -0,0 +1,143 @@
+// Package channels - notification-url-only adapters with no separate
+// name-registered Channel type: each registers a ChannelFactory directly
+// rather than a Parse method, since there is no existing instance to hang
+// one off of.
+package channels
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/config"
+)
+
+func init() {
+	Register("teams+https", parseTeamsTarget)
+	Register("pagerduty", parsePagerDutyTarget)
+	Register("mailto", parseMailtoTarget)
+}
+
+// teamsSender posts an Office 365 Connector Card to a Microsoft Teams
+// incoming webhook.
+type teamsSender struct {
+	url    string
+	client *http.Client
+}
+
+// parseTeamsTarget builds a Sender for a
+// "teams+https://outlook.office.com/webhook/..." notification-url, the
+// same "generic+" scheme-stripping convention WebhookChannel.Parse uses.
+func parseTeamsTarget(u *url.URL) (Sender, error) {
+	const prefix = "teams+"
+	if !strings.HasPrefix(u.Scheme, prefix) {
+		return nil, fmt.Errorf("teams notification-url scheme must be %shttps, got %q", prefix, u.Scheme)
+	}
+	target := *u
+	target.Scheme = strings.TrimPrefix(u.Scheme, prefix)
+	if target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported teams notification-url scheme: %q", u.Scheme)
+	}
+
+	return &teamsSender{url: target.String(), client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+func (t *teamsSender) Send(n *Notification) (map[string]interface{}, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"@type":    "MessageCard",
+		"@context": "http://schema.org/extensions",
+		"summary":  n.Subject,
+		"title":    n.Subject,
+		"text":     n.Message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.client.Post(t.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("teams webhook returned status %d", resp.StatusCode)
+	}
+	return map[string]interface{}{"sent_to": t.url}, nil
+}
+
+// pagerDutySender triggers an event via the PagerDuty Events API v2.
+type pagerDutySender struct {
+	routingKey string
+	client     *http.Client
+}
+
+// parsePagerDutyTarget builds a Sender for a
+// "pagerduty://ROUTING_KEY@events" notification-url.
+func parsePagerDutyTarget(u *url.URL) (Sender, error) {
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("pagerduty notification-url requires a routing key, e.g. pagerduty://ROUTING_KEY@events")
+	}
+	return &pagerDutySender{
+		routingKey: u.User.Username(),
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+func (p *pagerDutySender) Send(n *Notification) (map[string]interface{}, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"payload": map[string]interface{}{
+			"summary":  n.Subject,
+			"source":   "go_notifications",
+			"severity": "error",
+			"custom_details": map[string]interface{}{
+				"message": n.Message,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Post("https://events.pagerduty.com/v2/enqueue", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("pagerduty returned status %d", resp.StatusCode)
+	}
+	return map[string]interface{}{"routing_key": p.routingKey}, nil
+}
+
+// parseMailtoTarget builds a Sender for a "mailto:user@example.com"
+// notification-url. Unlike smtp://, a mailto: URL carries no relay host
+// or credentials to dial, so it always sends via the local sendmail
+// binary - the same transport EmailChannel uses when
+// SMTPConfig.Transport is "sendmail".
+func parseMailtoTarget(u *url.URL) (Sender, error) {
+	addr := u.Opaque
+	if addr == "" {
+		addr = strings.TrimPrefix(u.Path, "/")
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("mailto notification-url requires an address, e.g. mailto:user@example.com")
+	}
+	if _, err := mail.ParseAddress(addr); err != nil {
+		return nil, fmt.Errorf("invalid mailto address %q: %w", addr, err)
+	}
+
+	cfg := config.SMTPConfig{
+		From:      "notifications@localhost",
+		Transport: "sendmail",
+	}
+	return &boundEmailSender{channel: NewEmailChannel(cfg), to: addr}, nil
+}
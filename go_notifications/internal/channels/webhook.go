@@ -3,7 +3,7 @@ package channels
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,54 +18,173 @@ import (
 // WebhookChannel handles webhook notifications
 type WebhookChannel struct {
 	client  *http.Client
+	policy  WebhookPolicy
 	timeout time.Duration
+
+	// signingSecrets holds the HMAC secret to sign Send's payload with,
+	// keyed by exact recipient URL. Set via SetSigningSecrets; a
+	// recipient with no entry is sent unsigned.
+	signingSecrets map[string]string
 }
 
-// NewWebhookChannel creates a new webhook channel
+// NewWebhookChannel creates a webhook channel using DefaultWebhookPolicy.
 func NewWebhookChannel(timeout time.Duration) *WebhookChannel {
-	// VULN: No TLS certificate verification
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true, // VULN: Accepts any certificate
-		},
-		// VULN: Follows redirects to any domain
-		DisableKeepAlives: false,
-	}
-
-	client := &http.Client{
-		Timeout:   timeout,
-		Transport: transport,
-		// VULN: No redirect policy - follows all redirects
-	}
+	policy := DefaultWebhookPolicy()
+	policy.Timeout = timeout
+	return NewWebhookChannelWithPolicy(policy)
+}
 
+// NewWebhookChannelWithPolicy creates a webhook channel whose outbound
+// requests are all made through a client built by NewSecureHTTPClient(policy).
+func NewWebhookChannelWithPolicy(policy WebhookPolicy) *WebhookChannel {
 	return &WebhookChannel{
-		client:  client,
-		timeout: timeout,
+		client:  NewSecureHTTPClient(policy),
+		policy:  policy,
+		timeout: policy.Timeout,
 	}
 }
 
+// SetSigningSecrets configures the per-recipient HMAC secrets Send signs
+// outbound payloads with, mirroring Worker.SetMaxConcurrent's
+// setter-injection style.
+func (w *WebhookChannel) SetSigningSecrets(secrets map[string]string) {
+	w.signingSecrets = secrets
+}
+
 // Name returns the channel name
 func (w *WebhookChannel) Name() string {
 	return "webhook"
 }
 
+// Parse builds a Sender for a "generic+https://example.com/hook" or
+// "generic+http://..." notification-url: the "generic+" prefix is
+// stripped off the scheme to recover the real target URL, and everything
+// else about it (query string, path) passes through untouched. The
+// Sender uses a fresh WebhookChannel with DefaultWebhookPolicy - it has no
+// access to a pre-configured instance's signing secrets, since Parse is
+// called on the zero-value receiver registered below.
+func (w *WebhookChannel) Parse(u *url.URL) (Sender, error) {
+	const prefix = "generic+"
+	if !strings.HasPrefix(u.Scheme, prefix) {
+		return nil, fmt.Errorf("webhook notification-url scheme must be %shttp(s), got %q", prefix, u.Scheme)
+	}
+	target := *u
+	target.Scheme = strings.TrimPrefix(u.Scheme, prefix)
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported webhook notification-url scheme: %q", u.Scheme)
+	}
+
+	return &boundWebhookSender{
+		channel: NewWebhookChannel(DefaultWebhookPolicy().Timeout),
+		url:     target.String(),
+	}, nil
+}
+
+// boundWebhookSender adapts WebhookChannel to Sender for a Parse-built
+// destination: Send targets url rather than Notification.Recipient.
+type boundWebhookSender struct {
+	channel *WebhookChannel
+	url     string
+}
+
+func (b *boundWebhookSender) Send(n *Notification) (map[string]interface{}, error) {
+	notifCopy := *n
+	notifCopy.Recipient = b.url
+	return b.channel.Send(&notifCopy)
+}
+
+func init() {
+	Register("generic+http", (&WebhookChannel{}).Parse)
+	Register("generic+https", (&WebhookChannel{}).Parse)
+	RegisterNotifier("webhook", newWebhookNotifier)
+}
+
+// WebhookConfig is WebhookChannel's strongly-typed factory config, used by
+// the "webhook" notifier factory registered below.
+type WebhookConfig struct {
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// AllowedHosts, SigningSecrets, etc mirror WebhookPolicy and
+	// SetSigningSecrets. A zero Timeout or empty AllowedHosts falls back to
+	// DefaultWebhookPolicy's; RequireHTTPS is used as given, so leaving it
+	// unset allows plain http.
+	AllowedHosts   []string          `json:"allowed_hosts,omitempty"`
+	RequireHTTPS   bool              `json:"require_https,omitempty"`
+	SigningSecrets map[string]string `json:"signing_secrets,omitempty"`
+}
+
+// webhookNotifier adapts WebhookChannel to the Notifier interface for the
+// registry-driven factory path.
+type webhookNotifier struct {
+	*WebhookChannel
+}
+
+func newWebhookNotifier(cfg json.RawMessage) (Notifier, error) {
+	var c WebhookConfig
+	if err := json.Unmarshal(cfg, &c); err != nil {
+		return nil, fmt.Errorf("invalid webhook config: %w", err)
+	}
+
+	policy := DefaultWebhookPolicy()
+	if c.Timeout > 0 {
+		policy.Timeout = c.Timeout
+	}
+	if len(c.AllowedHosts) > 0 {
+		policy.AllowedHosts = c.AllowedHosts
+	}
+	if !c.RequireHTTPS {
+		policy.RequireHTTPS = false
+	}
+
+	ch := NewWebhookChannelWithPolicy(policy)
+	ch.SetSigningSecrets(c.SigningSecrets)
+	return &webhookNotifier{WebhookChannel: ch}, nil
+}
+
+func (w *webhookNotifier) Validate(cfg json.RawMessage) error {
+	var c WebhookConfig
+	return json.Unmarshal(cfg, &c)
+}
+
+func (w *webhookNotifier) Send(ctx context.Context, n *Notification) (Result, error) {
+	if err := w.WebhookChannel.Validate(n); err != nil {
+		return nil, err
+	}
+	return w.WebhookChannel.Send(n)
+}
+
+// WebhookChannelOf returns the *WebhookChannel backing n, if n was built by
+// the "webhook" notifier factory - for callers (Dispatcher, TestWebhook,
+// Worker's webhook job type) that need WebhookChannel's own methods
+// (SendToURL, FetchURLContent), which aren't part of the Notifier
+// interface.
+func WebhookChannelOf(n Notifier) *WebhookChannel {
+	if wn, ok := n.(*webhookNotifier); ok {
+		return wn.WebhookChannel
+	}
+	return nil
+}
+
 // Validate checks if the notification is valid for webhook
 func (w *WebhookChannel) Validate(n *Notification) error {
 	if n.Recipient == "" {
 		return fmt.Errorf("webhook URL is required")
 	}
-	// VULN: No URL validation - accepts any URL including internal IPs
 	return nil
 }
 
 // Send delivers the notification via webhook
-// TAINT SINK: URL (Recipient) is user-controlled - SSRF vulnerability
 func (w *WebhookChannel) Send(n *Notification) (map[string]interface{}, error) {
+	if err := w.ValidateWebhookURL(n.Recipient); err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
 	payload := map[string]interface{}{
-		"subject": n.Subject,
-		"message": n.Message,
-		"metadata": n.Metadata,
-		"timestamp": time.Now().Unix(),
+		"subject":   n.Subject,
+		"message":   n.Message,
+		"metadata":  n.Metadata,
+		"timestamp": timestamp,
 	}
 
 	body, err := json.Marshal(payload)
@@ -73,17 +192,16 @@ func (w *WebhookChannel) Send(n *Notification) (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	// VULN: SSRF - User-controlled URL, can access internal services
-	// n.Recipient could be:
-	// - http://169.254.169.254/latest/meta-data/ (AWS metadata)
-	// - http://localhost:6379/ (Redis)
-	// - http://internal-service:8080/admin (Internal admin)
-	req, err := http.NewRequest("POST", n.Recipient, bytes.NewBuffer(body)) // TAINT SINK
+	req, err := http.NewRequest("POST", n.Recipient, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey(n.ID))
+	if secret, ok := w.signingSecrets[n.Recipient]; ok && secret != "" {
+		req.Header.Set("X-Signature", signWebhookPayload(secret, timestamp, body))
+	}
 
 	// VULN: User-controlled headers
 	for key, value := range n.Metadata {
@@ -99,7 +217,7 @@ func (w *WebhookChannel) Send(n *Notification) (map[string]interface{}, error) {
 	}
 	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
+	respBody, _ := io.ReadAll(w.limitedBody(resp))
 
 	return map[string]interface{}{
 		"status_code": resp.StatusCode,
@@ -108,13 +226,16 @@ func (w *WebhookChannel) Send(n *Notification) (map[string]interface{}, error) {
 	}, nil
 }
 
-// SendToURL sends a request to any URL with custom method and headers
-// VULN: Full SSRF - arbitrary HTTP requests
+// SendToURL sends a request to targetURL with custom method and headers,
+// after validating it with ValidateWebhookURL. The underlying client
+// (built by NewSecureHTTPClient) re-validates the address again at connect
+// time and on every redirect.
 func (w *WebhookChannel) SendToURL(targetURL, method string, headers map[string]string, body string) (map[string]interface{}, error) {
-	// VULN: No URL validation at all
-	// No check for internal IPs, localhost, or cloud metadata endpoints
+	if err := w.ValidateWebhookURL(targetURL); err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
 
-	req, err := http.NewRequest(method, targetURL, strings.NewReader(body)) // TAINT SINK
+	req, err := http.NewRequest(method, targetURL, strings.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +251,7 @@ func (w *WebhookChannel) SendToURL(targetURL, method string, headers map[string]
 	}
 	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
+	respBody, _ := io.ReadAll(w.limitedBody(resp))
 
 	return map[string]interface{}{
 		"url":         targetURL,
@@ -141,6 +262,15 @@ func (w *WebhookChannel) SendToURL(targetURL, method string, headers map[string]
 	}, nil
 }
 
+// limitedBody caps how much of resp.Body callers will read, per
+// policy.MaxResponseBytes.
+func (w *WebhookChannel) limitedBody(resp *http.Response) io.Reader {
+	if w.policy.MaxResponseBytes <= 0 {
+		return resp.Body
+	}
+	return io.LimitReader(resp.Body, w.policy.MaxResponseBytes)
+}
+
 // SendWithCurl uses curl command for webhook delivery
 // VULN: Command injection via URL or headers
 func (w *WebhookChannel) SendWithCurl(targetURL string, headers map[string]string, body string) (string, error) {
@@ -160,56 +290,105 @@ func (w *WebhookChannel) SendWithCurl(targetURL string, headers map[string]strin
 	return string(output), err
 }
 
-// ValidateWebhookURL checks if URL is valid
-// VULN: Incomplete validation - doesn't block internal IPs
+// validatePolicyURL checks u's scheme and host allow-list membership.
+// Address-level checks (private/internal ranges) happen separately in
+// ValidateWebhookURL and in the safehttp.Client a WebhookChannel sends
+// through, since they require a DNS lookup.
+func validatePolicyURL(policy WebhookPolicy, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme: %q", u.Scheme)
+	}
+	if policy.RequireHTTPS && u.Scheme != "https" {
+		return fmt.Errorf("scheme %q not allowed: HTTPS required", u.Scheme)
+	}
+	return validateHostAllowList(policy, u.Hostname())
+}
+
+func validateHostAllowList(policy WebhookPolicy, host string) error {
+	if len(policy.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, allowed := range policy.AllowedHosts {
+		if _, network, err := net.ParseCIDR(allowed); err == nil {
+			if ip := net.ParseIP(host); ip != nil && network.Contains(ip) {
+				return nil
+			}
+			continue
+		}
+		if strings.EqualFold(host, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %q is not in the allow-list", host)
+}
+
+// ValidateWebhookURL checks targetURL's scheme, host allow-list membership,
+// and resolves its hostname to make sure no candidate address is internal
+// (private, loopback, link-local - including cloud metadata IPs - multicast,
+// or unspecified).
 func (w *WebhookChannel) ValidateWebhookURL(targetURL string) error {
+	return validateWebhookURL(w.policy, targetURL)
+}
+
+// validateWebhookURL is WebhookChannel.ValidateWebhookURL's implementation,
+// pulled out so other channels that accept a caller-supplied destination
+// URL (see DiscordChannel.Send) can apply the same checks against their own
+// WebhookPolicy without depending on a WebhookChannel instance.
+func validateWebhookURL(policy WebhookPolicy, targetURL string) error {
 	parsed, err := url.Parse(targetURL)
 	if err != nil {
 		return err
 	}
 
-	// Only checks scheme
-	if parsed.Scheme != "http" && parsed.Scheme != "https" {
-		return fmt.Errorf("invalid scheme: %s", parsed.Scheme)
+	if err := validatePolicyURL(policy, parsed); err != nil {
+		return err
 	}
 
-	// VULN: Doesn't check for:
-	// - localhost / 127.0.0.1
-	// - Private IP ranges (10.x, 172.16.x, 192.168.x)
-	// - Link-local (169.254.x)
-	// - Cloud metadata IPs
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if isInternalIP(ip) {
+			return fmt.Errorf("%s resolves to an internal address", host)
+		}
+		return nil
+	}
 
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return fmt.Errorf("resolve %q: %w", host, err)
+	}
+	for _, ip := range ips {
+		if isInternalIP(ip) {
+			return fmt.Errorf("%s resolves to internal address %s", host, ip)
+		}
+	}
 	return nil
 }
 
-// isInternalIP checks if IP is internal (not actually used - dead code)
+// isInternalIP reports whether ip (IPv4 or IPv6) falls into a private,
+// loopback, link-local (including the 169.254.169.254 cloud metadata
+// address and the IPv6 fd00::/8 unique-local range), multicast, or
+// unspecified range.
 func isInternalIP(ip net.IP) bool {
-	// This function exists but is never called - VULN: Dead code
-	privateRanges := []string{
-		"10.0.0.0/8",
-		"172.16.0.0/12",
-		"192.168.0.0/16",
-		"127.0.0.0/8",
-		"169.254.0.0/16",
-	}
-
-	for _, cidr := range privateRanges {
-		_, network, _ := net.ParseCIDR(cidr)
-		if network.Contains(ip) {
-			return true
-		}
-	}
-	return false
+	return ip.IsPrivate() ||
+		ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
 }
 
-// FetchURLContent fetches content from a URL
-// VULN: SSRF for content retrieval
+// FetchURLContent fetches content from targetURL after validating it with
+// ValidateWebhookURL.
 func (w *WebhookChannel) FetchURLContent(targetURL string) ([]byte, error) {
-	resp, err := w.client.Get(targetURL) // TAINT SINK: SSRF
+	if err := w.ValidateWebhookURL(targetURL); err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	resp, err := w.client.Get(targetURL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	return io.ReadAll(resp.Body)
+	return io.ReadAll(w.limitedBody(resp))
 }
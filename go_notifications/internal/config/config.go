@@ -2,34 +2,176 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"time"
 
+	"github.com/project-anarchy/go_notifications/internal/auditlog"
+	"github.com/project-anarchy/go_notifications/internal/secrets"
 	"gopkg.in/yaml.v3"
 )
 
 // Config holds all configuration for the notification service
 type Config struct {
-	ListenAddr     string        `yaml:"listen_addr"`
-	DatabasePath   string        `yaml:"database_path"`
-	TemplatesDir   string        `yaml:"templates_dir"`
-	LogDir         string        `yaml:"log_dir"`
-	APIKey         string        `yaml:"api_key"`
-	WorkerCount    int           `yaml:"worker_count"`
-	WebhookTimeout time.Duration `yaml:"webhook_timeout"`
-	SlackWebhook   string        `yaml:"slack_webhook"`
-	SMTP           SMTPConfig    `yaml:"smtp"`
-	Security       SecurityConfig `yaml:"security"`
+	ListenAddr    string `yaml:"listen_addr"`
+	DatabasePath  string `yaml:"database_path"`
+	TemplatesDir  string `yaml:"templates_dir"`
+	TemplateWatch bool   `yaml:"template_watch"`
+	LogDir        string `yaml:"log_dir"`
+
+	// APIKey seeds the initial rotated key in storage/apikeyrepo (see
+	// cmd/server/main.go) on first run. Stored envelope-encrypted on
+	// disk (a "!enc"-tagged YAML value) and decrypted by Load via
+	// Secrets' configured KeyProvider; Reveal() returns the plaintext.
+	APIKey         secrets.SecretString `yaml:"api_key"`
+	WorkerCount    int                  `yaml:"worker_count"`
+	WebhookTimeout time.Duration        `yaml:"webhook_timeout"`
+
+	// SlackWebhook is the default, unnamed Slack workspace's incoming
+	// webhook URL - see SlackWorkspaces for additional named ones.
+	// Stored envelope-encrypted like APIKey.
+	SlackWebhook secrets.SecretString `yaml:"slack_webhook"`
+
+	// SlackBotToken, if set, configures a channels.SlackAPIClient (wired
+	// onto the "slack" notifier's SlackChannel via SetAPIClient) so
+	// PostToChannel and UploadFile can use the Slack Web API instead of
+	// failing with "requires SetAPIClient". Unset disables both.
+	SlackBotToken string         `yaml:"slack_bot_token"` // VULN: Stored in plaintext
+	SMTP          SMTPConfig     `yaml:"smtp"`
+	Security      SecurityConfig `yaml:"security"`
+
+	// Auth configures the pluggable authenticators api.AuthMiddleware
+	// chains together for /api routes, replacing the single static
+	// APIKey above as the API's primary authentication path. APIKey is
+	// still read by cmd/server/main.go to seed the initial rotated key
+	// (see storage/apikeyrepo) on first run.
+	Auth AuthConfig `yaml:"auth"`
+
+	// Secrets selects the secrets.KeyProvider Load uses to decrypt
+	// "!enc"-tagged fields (APIKey, SlackWebhook, SMTP.Password).
+	Secrets SecretsConfig `yaml:"secrets"`
+
+	// SlackWorkspaces configures additional named Slack workspaces,
+	// keyed by the name a caller sets in Notification.Metadata["workspace"]
+	// - see Dispatcher.RegisterSlackWorkspace. SlackWebhook above remains
+	// the default, unnamed workspace.
+	SlackWorkspaces map[string]string `yaml:"slack_workspaces"`
+
+	// Additional notification providers. Each is disabled until its
+	// required fields are set; see cmd/server/main.go for the wiring.
+	SMS      SMSProviderConfig      `yaml:"sms"`
+	Telegram TelegramProviderConfig `yaml:"telegram"`
+	Discord  DiscordProviderConfig  `yaml:"discord"`
+	Push     PushProviderConfig     `yaml:"push"`
+
+	// ChannelRateLimits caps outbound messages per minute, keyed by
+	// channel name (e.g. "sms": 60). A channel absent here is unlimited.
+	ChannelRateLimits map[string]int `yaml:"channel_rate_limits"`
+
+	// WebhookDelivery configures signing and retry/dead-letter behavior
+	// for the durable webhook delivery subsystem.
+	WebhookDelivery WebhookDeliveryConfig `yaml:"webhook_delivery"`
+
+	// Callbacks configures inbound signature verification for
+	// /api/callback/{id}, keyed by that id. An id with no entry here is
+	// rejected outright.
+	Callbacks map[string]CallbackConfig `yaml:"callbacks"`
+
+	// RPC configures the queue/rpc remote worker protocol - the /rpc
+	// WebSocket endpoint cmd/agent processes connect to.
+	RPC RPCConfig `yaml:"rpc"`
+}
+
+// RPCConfig configures the /rpc WebSocket endpoint remote cmd/agent
+// processes pull jobs from.
+type RPCConfig struct {
+	// Enabled turns on the /rpc endpoint. Defaults to false, so a server
+	// with no remote workers doesn't expose it at all.
+	Enabled bool `yaml:"enabled"`
+	// SharedSecret authenticates a connecting agent; see cmd/agent's
+	// -secret flag.
+	SharedSecret string `yaml:"shared_secret"` // VULN: Stored in plaintext
+	// LeaseTTL bounds how long a leased job may run before the reaper
+	// requeues it for another worker. Defaults to rpc.DefaultLeaseTTL.
+	LeaseTTL time.Duration `yaml:"lease_ttl"`
+	// ReapInterval is how often the background reaper scans for expired
+	// leases. Defaults to rpc.DefaultReapInterval.
+	ReapInterval time.Duration `yaml:"reap_interval"`
+}
+
+// CallbackConfig configures how ProcessCallback verifies one callback id's
+// inbound signature. The signing secret itself isn't here: it lives in
+// SQLiteStore's callback_secrets table (see storage/callbackrepo), set via
+// the POST /api/admin/callbacks/{id}/secret admin endpoint so it can be
+// rotated without a restart or a plaintext config edit.
+type CallbackConfig struct {
+	// Provider selects the signature header format: "stripe", "github",
+	// "slack", or "internal" (this service's own X-Signature-256 /
+	// X-Signature-Timestamp scheme).
+	Provider string `yaml:"provider"`
+}
+
+// WebhookDeliveryConfig configures how outbound webhook notifications are
+// signed and how failed deliveries are retried before being dead-lettered.
+type WebhookDeliveryConfig struct {
+	// SigningSecrets holds the HMAC secret to sign each recipient's
+	// requests with, keyed by the exact recipient URL. A recipient with
+	// no entry here is sent unsigned.
+	SigningSecrets map[string]string `yaml:"signing_secrets"` // VULN: Stored in plaintext
+
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay"`
+	MaxAttempts    int           `yaml:"max_attempts"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+}
+
+// SMSProviderConfig holds Twilio-style SMS provider configuration.
+type SMSProviderConfig struct {
+	AccountSID string `yaml:"account_sid"`
+	AuthToken  string `yaml:"auth_token"` // VULN: Stored in plaintext
+	From       string `yaml:"from"`
+	BaseURL    string `yaml:"base_url"`
+}
+
+// TelegramProviderConfig holds Telegram bot provider configuration.
+type TelegramProviderConfig struct {
+	BotToken string `yaml:"bot_token"` // VULN: Stored in plaintext
+	BaseURL  string `yaml:"base_url"`
+}
+
+// DiscordProviderConfig holds Discord incoming webhook configuration.
+type DiscordProviderConfig struct {
+	WebhookURL string `yaml:"webhook_url"`
+}
+
+// PushProviderConfig holds FCM push provider configuration.
+type PushProviderConfig struct {
+	ServerKey string `yaml:"server_key"` // VULN: Stored in plaintext
+	Endpoint  string `yaml:"endpoint"`
 }
 
 // SMTPConfig holds SMTP server configuration
 type SMTPConfig struct {
-	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"` // VULN: Stored in plaintext
-	From     string `yaml:"from"`
-	UseTLS   bool   `yaml:"use_tls"`
+	Host     string               `yaml:"host"`
+	Port     int                  `yaml:"port"`
+	Username string               `yaml:"username"`
+	Password secrets.SecretString `yaml:"password"`
+	From     string               `yaml:"from"`
+	UseTLS   bool                 `yaml:"use_tls"`
+
+	// InsecureSkipVerify disables TLS certificate verification against the
+	// SMTP server. Defaults to false; only set true for a local test
+	// server with a self-signed cert, never in production.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+
+	// Transport selects how EmailChannel.Send delivers the built message:
+	// "" or "smtp" (default) dials Host:Port directly; "sendmail" shells
+	// to SendmailPath with the message on stdin.
+	Transport string `yaml:"transport"`
+
+	// SendmailPath overrides the sendmail binary used when Transport is
+	// "sendmail". Defaults to /usr/sbin/sendmail.
+	SendmailPath string `yaml:"sendmail_path"`
 }
 
 // SecurityConfig holds security-related settings
@@ -39,10 +181,164 @@ type SecurityConfig struct {
 	EnableAuditLog  bool     `yaml:"enable_audit_log"`
 	HooksEnabled    bool     `yaml:"hooks_enabled"`
 	HooksDir        string   `yaml:"hooks_dir"`
+
+	// RateLimitBurst is the burst size api.RateLimit allows on top of
+	// RateLimitPerMin's steady refill rate. <= 0 defaults to
+	// RateLimitPerMin (no extra burst capacity).
+	RateLimitBurst int `yaml:"rate_limit_burst"`
+
+	// TrustedProxies lists the CIDRs api.RealClientIP trusts to have set
+	// X-Forwarded-For/X-Real-IP honestly (e.g. a load balancer's subnet).
+	// Empty means no proxy is trusted, so those headers are always
+	// ignored and api.RateLimit keys on r.RemoteAddr directly.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	// RequestLog configures rotation for api.LoggingMiddleware's
+	// structured per-request log, always active regardless of
+	// EnableAuditLog.
+	RequestLog auditlog.Config `yaml:"request_log"`
+
+	// AuditLog configures rotation for api.AuditMiddleware's structured
+	// audit trail, active only when EnableAuditLog is true.
+	AuditLog auditlog.Config `yaml:"audit_log"`
+
+	// CORS configures api.CORSMiddleware's origin allowlist, replacing
+	// the old reflect-any-origin-and-always-allow-credentials behavior.
+	CORS CORSConfig `yaml:"cors"`
 }
 
-// Load reads configuration from a YAML file
-func Load(path string) (*Config, error) {
+// CORSConfig mirrors api.CORSConfig (kept as its own type so this package
+// doesn't import internal/api); cmd/server/main.go converts one to the
+// other when building the router.
+type CORSConfig struct {
+	// AllowedOrigins lists origins to echo back in
+	// Access-Control-Allow-Origin: an exact origin, a "*.example.com"
+	// wildcard suffix, or the literal "*". Empty means no Origin ever
+	// matches, so cross-origin requests get no CORS headers at all.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	AllowedMethods []string `yaml:"allowed_methods"`
+	AllowedHeaders []string `yaml:"allowed_headers"`
+	ExposedHeaders []string `yaml:"exposed_headers"`
+
+	// AllowCredentials sets Access-Control-Allow-Credentials: true for a
+	// matched origin. Ignored if AllowedOrigins contains "*" - the CORS
+	// spec forbids combining a wildcard origin with credentials.
+	AllowCredentials bool `yaml:"allow_credentials"`
+
+	// MaxAge sets Access-Control-Max-Age on preflight responses.
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// AuthConfig configures the authenticators api.AuthMiddleware chains
+// together per route group (see Routes), and the role RequireRole checks
+// for /api/admin/* routes.
+type AuthConfig struct {
+	// APIKeysEnabled turns on the "apikey" authenticator, backed by
+	// storage.SQLiteStore's api_keys table (storage/apikeyrepo) rather
+	// than the single static Config.APIKey.
+	APIKeysEnabled bool `yaml:"api_keys_enabled"`
+
+	// Basic configures the "basic" authenticator, backed by an htpasswd
+	// file.
+	Basic BasicAuthConfig `yaml:"basic"`
+
+	// OIDC configures the "oidc" authenticator, validating Bearer JWTs
+	// against an issuer's JWKS.
+	OIDC OIDCAuthConfig `yaml:"oidc"`
+
+	// Routes lists, per route group, which named authenticators ("apikey",
+	// "basic", "oidc") its auth.Chain tries, in order. "api" gates every
+	// /api route. An empty list means no authenticator runs - every
+	// request is rejected, since an empty auth.Chain never succeeds.
+	Routes map[string][]string `yaml:"routes"`
+
+	// AdminRole is the role RequireRole requires for /api/admin/* routes,
+	// checked against the Identity an "api" authenticator resolved.
+	AdminRole string `yaml:"admin_role"`
+}
+
+// BasicAuthConfig configures auth.BasicAuthenticator.
+type BasicAuthConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	HtpasswdPath string `yaml:"htpasswd_path"`
+}
+
+// OIDCAuthConfig configures auth.OIDCAuthenticator.
+type OIDCAuthConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Issuer    string        `yaml:"issuer"`
+	JWKSURL   string        `yaml:"jwks_url"`
+	Audience  string        `yaml:"audience"`
+	RoleClaim string        `yaml:"role_claim"`
+	CacheTTL  time.Duration `yaml:"cache_ttl"`
+}
+
+// SecretsConfig selects the secrets.KeyProvider config.Load builds to
+// decrypt "!enc"-tagged SecretString fields (Config.APIKey,
+// Config.SlackWebhook, SMTPConfig.Password).
+type SecretsConfig struct {
+	// Provider selects which KeyProvider to build: "env", "file", "age",
+	// or "kms". Empty disables decryption entirely - Load then refuses
+	// to start if any field is "!enc"-tagged.
+	Provider string `yaml:"provider"`
+
+	// Env is the environment variable an "env" provider reads a
+	// base64-encoded 32-byte KEK from.
+	Env string `yaml:"env"`
+
+	// Path is the file a "file" provider reads a base64-encoded 32-byte
+	// KEK from.
+	Path string `yaml:"path"`
+
+	// Age configures an "age" provider (secrets.AgeX25519KeyProvider).
+	Age AgeKeyConfig `yaml:"age"`
+
+	// KMS configures a "kms" provider (secrets.KMSKeyProvider).
+	KMS KMSKeyConfig `yaml:"kms"`
+}
+
+// AgeKeyConfig configures secrets.AgeX25519KeyProvider.
+type AgeKeyConfig struct {
+	IdentityPath       string `yaml:"identity_path"`
+	EphemeralPublicKey string `yaml:"ephemeral_public_key"`
+	WrappedKey         string `yaml:"wrapped_key"`
+}
+
+// KMSKeyConfig configures secrets.KMSKeyProvider.
+type KMSKeyConfig struct {
+	URL    string `yaml:"url"`
+	Bearer string `yaml:"bearer"`
+}
+
+// buildKeyProvider builds the secrets.KeyProvider cfg.Provider names, or
+// nil if Provider is unset.
+func (cfg SecretsConfig) buildKeyProvider() (secrets.KeyProvider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "env":
+		return secrets.EnvKeyProvider{Var: cfg.Env}, nil
+	case "file":
+		return secrets.FileKeyProvider{Path: cfg.Path}, nil
+	case "age":
+		return secrets.AgeX25519KeyProvider{
+			IdentityPath:       cfg.Age.IdentityPath,
+			EphemeralPublicKey: cfg.Age.EphemeralPublicKey,
+			WrappedKey:         cfg.Age.WrappedKey,
+		}, nil
+	case "kms":
+		return secrets.NewKMSKeyProvider(cfg.KMS.URL, cfg.KMS.Bearer), nil
+	default:
+		return nil, fmt.Errorf("config: unknown secrets provider %q", cfg.Provider)
+	}
+}
+
+// Load reads configuration from a YAML file, then decrypts every
+// "!enc"-tagged secret field using the KeyProvider Secrets names.
+// allowPlaintextSecrets must be true (the "--allow-plaintext-secrets"
+// flag) for Load to accept a secret field that's plaintext on disk
+// instead of "!enc"-tagged ciphertext.
+func Load(path string, allowPlaintextSecrets bool) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -59,12 +355,25 @@ func Load(path string) (*Config, error) {
 		cfg.ListenAddr = envAddr
 	}
 	if envKey := os.Getenv("NOTIFY_API_KEY"); envKey != "" {
-		cfg.APIKey = envKey
+		// An env var override is inherently plaintext at the point it
+		// reaches this process; it bypasses the "!enc"/allow-plaintext
+		// gate entirely rather than being held to it.
+		cfg.APIKey = secrets.Plain(envKey)
 	}
 	if envDB := os.Getenv("NOTIFY_DATABASE_PATH"); envDB != "" {
 		cfg.DatabasePath = envDB
 	}
 
+	provider, err := cfg.Secrets.buildKeyProvider()
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range []*secrets.SecretString{&cfg.APIKey, &cfg.SlackWebhook, &cfg.SMTP.Password} {
+		if err := secrets.Resolve(field, provider, allowPlaintextSecrets); err != nil {
+			return nil, err
+		}
+	}
+
 	return &cfg, nil
 }
 
@@ -74,16 +383,17 @@ func Default() *Config {
 		ListenAddr:     ":8082",
 		DatabasePath:   "./notifications.db",
 		TemplatesDir:   "./templates",
+		TemplateWatch:  false,
 		LogDir:         "./logs",
-		APIKey:         "dev-api-key-12345", // VULN: Hardcoded default API key
+		APIKey:         secrets.Plain("dev-api-key-12345"), // VULN: Hardcoded default API key
 		WorkerCount:    4,
 		WebhookTimeout: 30 * time.Second,
-		SlackWebhook:   "",
+		SlackWebhook:   secrets.Plain(""),
 		SMTP: SMTPConfig{
 			Host:     "localhost",
 			Port:     25,
 			Username: "",
-			Password: "",
+			Password: secrets.Plain(""),
 			From:     "notifications@localhost",
 			UseTLS:   false,
 		},
@@ -93,6 +403,45 @@ func Default() *Config {
 			EnableAuditLog:  false,
 			HooksEnabled:    true,
 			HooksDir:        "./scripts/hooks",
+			RequestLog: auditlog.Config{
+				Path:       "./logs/requests.log",
+				MaxSizeMB:  100,
+				MaxAgeDays: 30,
+				MaxBackups: 5,
+				Compress:   true,
+			},
+			AuditLog: auditlog.Config{
+				Path:       "./logs/audit.log",
+				MaxSizeMB:  100,
+				MaxAgeDays: 90,
+				MaxBackups: 10,
+				Compress:   true,
+			},
+			CORS: CORSConfig{
+				AllowedOrigins:   []string{},
+				AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+				AllowedHeaders:   []string{"Content-Type", "Authorization", "X-API-Key", "X-Request-ID"},
+				AllowCredentials: false,
+				MaxAge:           10 * time.Minute,
+			},
+		},
+		WebhookDelivery: WebhookDeliveryConfig{
+			RetryBaseDelay: 2 * time.Second,
+			RetryMaxDelay:  5 * time.Minute,
+			MaxAttempts:    6,
+			PollInterval:   5 * time.Second,
+		},
+		RPC: RPCConfig{
+			Enabled:      false,
+			LeaseTTL:     5 * time.Minute,
+			ReapInterval: 30 * time.Second,
+		},
+		Auth: AuthConfig{
+			APIKeysEnabled: true,
+			Routes: map[string][]string{
+				"api": {"apikey"},
+			},
+			AdminRole: "admin",
 		},
 	}
 }
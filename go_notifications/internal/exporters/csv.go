@@ -0,0 +1,70 @@
+package exporters
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/project-anarchy/go_notifications/internal/channels"
+)
+
+// csvFormulaPrefixes are the leading characters spreadsheet software
+// (Excel, Sheets) treats as active content rather than plain text: '=',
+// '+', '-', '@', plus tab and CR, which some importers also treat
+// specially.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// CSVFormat encodes notifications as CSV via encoding/csv, which quotes
+// and escapes values containing commas, quotes, or newlines
+// automatically. When SafeMode is set, a value leading with a
+// formula-trigger character is prefixed with a single quote, neutralizing
+// CSV injection in spreadsheet software; the quote is written into the
+// value, so plain-text consumers see it too.
+type CSVFormat struct {
+	SafeMode bool
+}
+
+// ContentType returns the CSV MIME type.
+func (f CSVFormat) ContentType() string { return "text/csv" }
+
+// Extension returns the CSV file extension.
+func (f CSVFormat) Extension() string { return "csv" }
+
+// Encoder returns a RowEncoder that writes the header row immediately.
+func (f CSVFormat) Encoder(w io.Writer) RowEncoder {
+	cw := csv.NewWriter(w)
+	cw.Write(Columns)
+	return &csvEncoder{w: cw, safe: f.SafeMode}
+}
+
+type csvEncoder struct {
+	w    *csv.Writer
+	safe bool
+}
+
+func (e *csvEncoder) WriteRow(n *channels.Notification) error {
+	row := Row(n)
+	if e.safe {
+		for i, v := range row {
+			row[i] = escapeCSVFormula(v)
+		}
+	}
+	return e.w.Write(row)
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// escapeCSVFormula prefixes v with a single quote if it leads with a
+// character a spreadsheet would interpret as a formula trigger.
+func escapeCSVFormula(v string) string {
+	if v == "" {
+		return v
+	}
+	if strings.IndexByte(csvFormulaPrefixes, v[0]) >= 0 {
+		return "'" + v
+	}
+	return v
+}
@@ -0,0 +1,105 @@
+package exporters
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/channels"
+)
+
+func TestEscapeCSVFormula(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain text untouched", "hello", "hello"},
+		{"empty string untouched", "", ""},
+		{"equals prefix neutralized", "=SUM(A1:A10)", "'=SUM(A1:A10)"},
+		{"plus prefix neutralized", "+1-800-555-0100", "'+1-800-555-0100"},
+		{"minus prefix neutralized", "-2+3", "'-2+3"},
+		{"at prefix neutralized", "@cmd|'/c calc'!A1", "'@cmd|'/c calc'!A1"},
+		{"tab prefix neutralized", "\tmalicious", "'\tmalicious"},
+		{"cr prefix neutralized", "\rmalicious", "'\rmalicious"},
+		{"formula char mid-string untouched", "total=5", "total=5"},
+		{"unicode text untouched", "héllo wörld", "héllo wörld"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := escapeCSVFormula(c.value); got != c.want {
+				t.Errorf("escapeCSVFormula(%q) = %q, want %q", c.value, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCSVEncoder_SafeModeNeutralizesFormulaInjection(t *testing.T) {
+	n := &channels.Notification{
+		ID:        1,
+		Channel:   "email",
+		Recipient: "=HYPERLINK(\"http://evil.example\",\"click me\")",
+		Subject:   "ok",
+		Message:   "ok",
+		Status:    "sent",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	enc := CSVFormat{SafeMode: true}.Encoder(&buf)
+	if err := enc.WriteRow(n); err != nil {
+		t.Fatalf("WriteRow failed: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "\"=HYPERLINK") {
+		t.Errorf("expected formula-leading recipient to be neutralized in safe mode, got: %s", out)
+	}
+	if !strings.Contains(out, "'=HYPERLINK") {
+		t.Errorf("expected neutralized value to keep a leading single quote, got: %s", out)
+	}
+}
+
+func TestCSVEncoder_UnsafeModeLeavesFormulaUntouched(t *testing.T) {
+	n := &channels.Notification{
+		ID:        1,
+		Channel:   "email",
+		Recipient: "=HYPERLINK(\"http://evil.example\",\"click me\")",
+		Subject:   "ok",
+		Message:   "ok",
+		Status:    "sent",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	enc := CSVFormat{SafeMode: false}.Encoder(&buf)
+	if err := enc.WriteRow(n); err != nil {
+		t.Fatalf("WriteRow failed: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "=HYPERLINK") {
+		t.Errorf("expected SafeMode=false to leave the formula value unescaped, got: %s", buf.String())
+	}
+}
+
+func TestCSVFormat_HeaderRow(t *testing.T) {
+	var buf bytes.Buffer
+	enc := CSVFormat{}.Encoder(&buf)
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	header := strings.TrimSpace(buf.String())
+	want := strings.Join(Columns, ",")
+	if header != want {
+		t.Errorf("header row = %q, want %q", header, want)
+	}
+}
@@ -0,0 +1,102 @@
+// Package exporters streams notification exports to an io.Writer in
+// CSV, XML, NDJSON, or Parquet format, in the stable column order given
+// by Columns rather than iterating a map in random order.
+package exporters
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/channels"
+)
+
+// Columns is the stable column order every row-based exporter writes
+// notifications in.
+var Columns = []string{"id", "channel", "recipient", "subject", "message", "status", "error", "created_at", "sent_at"}
+
+// Row renders one notification's values in Columns order.
+func Row(n *channels.Notification) []string {
+	sentAt := ""
+	if n.SentAt != nil {
+		sentAt = n.SentAt.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(n.ID, 10),
+		n.Channel,
+		n.Recipient,
+		n.Subject,
+		n.Message,
+		n.Status,
+		n.Error,
+		n.CreatedAt.Format(time.RFC3339),
+		sentAt,
+	}
+}
+
+// Format is a streaming export encoding (CSV, XML, NDJSON, Parquet, ...).
+type Format interface {
+	ContentType() string
+	Extension() string
+	// Encoder returns a fresh RowEncoder bound to w. Encoder may write a
+	// format preamble (e.g. a CSV header row or an XML root open tag)
+	// before returning.
+	Encoder(w io.Writer) RowEncoder
+}
+
+// RowEncoder streams one notification at a time to the writer it was
+// created with.
+type RowEncoder interface {
+	WriteRow(n *channels.Notification) error
+	// Close writes any closing content (e.g. an XML root close tag) and
+	// flushes buffered output. It does not close the underlying writer.
+	Close() error
+}
+
+// Limits bounds how much a single export can produce, so a request can't
+// hold a response open indefinitely or exhaust memory/disk on either end.
+type Limits struct {
+	MaxRows  int
+	MaxBytes int64
+}
+
+// DefaultLimits caps an export at 10,000 rows or 50MB, whichever comes
+// first.
+var DefaultLimits = Limits{MaxRows: 10000, MaxBytes: 50 << 20}
+
+// Export writes rows through format to w, honoring limits. It returns an
+// error (without partial cleanup - the caller owns w) if MaxBytes is
+// exceeded mid-stream.
+func Export(w io.Writer, format Format, rows []*channels.Notification, limits Limits) error {
+	if limits.MaxRows > 0 && len(rows) > limits.MaxRows {
+		rows = rows[:limits.MaxRows]
+	}
+
+	cw := &countingWriter{w: w, limit: limits.MaxBytes}
+	enc := format.Encoder(cw)
+
+	for _, n := range rows {
+		if err := enc.WriteRow(n); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// countingWriter errors out once more than limit bytes have passed
+// through it, rather than letting an export grow unbounded.
+type countingWriter struct {
+	w       io.Writer
+	written int64
+	limit   int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.limit > 0 && c.written+int64(len(p)) > c.limit {
+		return 0, fmt.Errorf("export exceeds %d byte limit", c.limit)
+	}
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	return n, err
+}
@@ -0,0 +1,37 @@
+package exporters
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/project-anarchy/go_notifications/internal/channels"
+)
+
+// NDJSONFormat encodes notifications as newline-delimited JSON, one
+// object per line. json.Encoder.Encode already escapes control
+// characters (including CR/LF) inside string fields, so adversarial
+// subjects/messages can't break the line framing.
+type NDJSONFormat struct{}
+
+// ContentType returns the NDJSON MIME type.
+func (f NDJSONFormat) ContentType() string { return "application/x-ndjson" }
+
+// Extension returns the NDJSON file extension.
+func (f NDJSONFormat) Extension() string { return "ndjson" }
+
+// Encoder returns a RowEncoder that writes one JSON object per line.
+func (f NDJSONFormat) Encoder(w io.Writer) RowEncoder {
+	return &ndjsonEncoder{enc: json.NewEncoder(w)}
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) WriteRow(n *channels.Notification) error {
+	return e.enc.Encode(n)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
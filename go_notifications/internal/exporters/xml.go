@@ -0,0 +1,78 @@
+package exporters
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/channels"
+)
+
+// xmlRecord is the per-notification element XMLFormat encodes. Using a
+// typed struct with xml.Encoder.Encode, rather than building
+// map[string]interface{} and hand-rolling tags, gets correct escaping of
+// '<', '>', '&', and the "]]>" CDATA-closing sequence for free from
+// encoding/xml.
+type xmlRecord struct {
+	XMLName   xml.Name `xml:"notification"`
+	ID        int64    `xml:"id"`
+	Channel   string   `xml:"channel"`
+	Recipient string   `xml:"recipient"`
+	Subject   string   `xml:"subject"`
+	Message   string   `xml:"message"`
+	Status    string   `xml:"status"`
+	Error     string   `xml:"error,omitempty"`
+	CreatedAt string   `xml:"created_at"`
+	SentAt    string   `xml:"sent_at,omitempty"`
+}
+
+// XMLFormat encodes notifications as XML, one <notification> element per
+// record, inside a <notifications> root.
+type XMLFormat struct{}
+
+// ContentType returns the XML MIME type.
+func (f XMLFormat) ContentType() string { return "application/xml" }
+
+// Extension returns the XML file extension.
+func (f XMLFormat) Extension() string { return "xml" }
+
+// Encoder writes the XML declaration and opens the root element.
+func (f XMLFormat) Encoder(w io.Writer) RowEncoder {
+	io.WriteString(w, xml.Header)
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	root := xml.StartElement{Name: xml.Name{Local: "notifications"}}
+	enc.EncodeToken(root)
+
+	return &xmlEncoder{enc: enc, root: root}
+}
+
+type xmlEncoder struct {
+	enc  *xml.Encoder
+	root xml.StartElement
+}
+
+func (e *xmlEncoder) WriteRow(n *channels.Notification) error {
+	rec := xmlRecord{
+		ID:        n.ID,
+		Channel:   n.Channel,
+		Recipient: n.Recipient,
+		Subject:   n.Subject,
+		Message:   n.Message,
+		Status:    n.Status,
+		Error:     n.Error,
+		CreatedAt: n.CreatedAt.Format(time.RFC3339),
+	}
+	if n.SentAt != nil {
+		rec.SentAt = n.SentAt.Format(time.RFC3339)
+	}
+	return e.enc.Encode(rec)
+}
+
+func (e *xmlEncoder) Close() error {
+	if err := e.enc.EncodeToken(e.root.End()); err != nil {
+		return err
+	}
+	return e.enc.Flush()
+}
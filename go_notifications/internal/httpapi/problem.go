@@ -0,0 +1,33 @@
+// Package httpapi provides response helpers shared across the HTTP API
+// surface, so every handler and middleware reports errors in the same
+// shape.
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Problem is an RFC 7807 "problem details for HTTP APIs" error body.
+type Problem struct {
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// WriteProblem writes an application/problem+json response built from
+// status and detail, carrying corrID (typically the request's
+// X-Request-ID, see api.RequestIDMiddleware) so a client can point an
+// operator at the matching server-side log entry without the response
+// itself needing to contain anything sensitive.
+func WriteProblem(w http.ResponseWriter, status int, detail, corrID string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        detail,
+		CorrelationID: corrID,
+	})
+}
@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/storage"
+)
+
+// Acquirer lets worker goroutines block on job availability instead of
+// polling the database on a fixed interval. It multiplexes any number of
+// callers onto a single store, woken by storage.SQLiteStore.Notify()
+// whenever a job is saved.
+type Acquirer struct {
+	store *storage.SQLiteStore
+
+	// pollFallback bounds how long AcquireJob waits between retries when it
+	// has missed a notification (e.g. a job whose scheduled_at has since
+	// elapsed). It trades a little of the latency win for simplicity.
+	pollFallback time.Duration
+}
+
+// NewAcquirer creates an Acquirer backed by store.
+func NewAcquirer(store *storage.SQLiteStore) *Acquirer {
+	return &Acquirer{
+		store:        store,
+		pollFallback: 5 * time.Second,
+	}
+}
+
+// AcquireJob blocks until a job matching tags is claimed for workerID, ctx
+// is cancelled, or an error occurs. tags is the worker's declared
+// capability set; a job that carries RequiredTags only matches a worker
+// whose tags are a superset of them.
+func (a *Acquirer) AcquireJob(ctx context.Context, workerID string, tags map[string]string) (*Job, error) {
+	for {
+		id, data, ok, err := a.store.AcquireJob(workerID, tags)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			var job Job
+			if err := json.Unmarshal([]byte(data), &job); err != nil {
+				continue
+			}
+			job.ID = id
+			return &job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-a.store.Notify():
+		case <-time.After(a.pollFallback):
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package rpc implements a pull-based remote worker protocol: a remote
+// cmd/agent process dials the server's /rpc WebSocket endpoint and speaks
+// JSON-RPC 2.0 to lease jobs, report progress, renew its lease, and report
+// results - modeled on Drone CI's agent/server split, with this service's
+// jobs table standing in for Drone's pipeline queue.
+package rpc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/queue"
+)
+
+// JSON-RPC 2.0 method names exposed over the /rpc WebSocket.
+const (
+	MethodNext   = "Next"
+	MethodUpdate = "Update"
+	MethodLog    = "Log"
+	MethodDone   = "Done"
+	MethodExtend = "Extend"
+)
+
+// Request is one JSON-RPC 2.0 call.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is one JSON-RPC 2.0 reply. Exactly one of Result/Error is set.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC 2.0 error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Standard JSON-RPC 2.0 error codes used by this server, plus ErrCodeAuth
+// in the server error reserved range (-32000 to -32099).
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+	ErrCodeAuth           = -32000
+)
+
+// NextParams requests the next job matching Tags for WorkerID.
+type NextParams struct {
+	WorkerID string            `json:"worker_id"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// NextResult carries the leased job, or Empty=true if none was available.
+type NextResult struct {
+	Empty bool       `json:"empty"`
+	Job   *queue.Job `json:"job,omitempty"`
+}
+
+// UpdateParams reports a status transition for a leased job.
+type UpdateParams struct {
+	JobID    string `json:"job_id"`
+	WorkerID string `json:"worker_id"`
+	Status   string `json:"status"`
+}
+
+// LogParams appends one line of job output. There is no per-job log store
+// yet, so the server currently just writes these to its own process log.
+type LogParams struct {
+	JobID string `json:"job_id"`
+	Line  string `json:"line"`
+}
+
+// DoneParams reports a leased job's terminal outcome.
+type DoneParams struct {
+	JobID    string                 `json:"job_id"`
+	WorkerID string                 `json:"worker_id"`
+	Status   string                 `json:"status"`
+	Error    string                 `json:"error,omitempty"`
+	Result   map[string]interface{} `json:"result,omitempty"`
+}
+
+// ExtendParams renews a leased job's visibility timeout by TTL.
+type ExtendParams struct {
+	JobID    string        `json:"job_id"`
+	WorkerID string        `json:"worker_id"`
+	TTL      time.Duration `json:"ttl"`
+}
+
+// ExtendResult reports whether the lease was found and still owned by
+// WorkerID; false means the job was already reaped and reassigned.
+type ExtendResult struct {
+	Ok bool `json:"ok"`
+}
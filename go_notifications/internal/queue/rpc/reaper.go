@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultReapInterval is how often Reaper scans for expired leases when
+// not otherwise configured.
+const DefaultReapInterval = 30 * time.Second
+
+// LeaseStore is the persistence method Reaper depends on.
+type LeaseStore interface {
+	ReapExpiredLeases() (int64, error)
+}
+
+// Reaper periodically requeues jobs whose lease (locked_until) has expired
+// without the leasing agent calling Done or Extend - most often because
+// the agent process died or lost its connection mid-job.
+type Reaper struct {
+	store    LeaseStore
+	interval time.Duration
+}
+
+// NewReaper creates a Reaper. interval defaults to DefaultReapInterval if
+// not positive.
+func NewReaper(store LeaseStore, interval time.Duration) *Reaper {
+	if interval <= 0 {
+		interval = DefaultReapInterval
+	}
+	return &Reaper{store: store, interval: interval}
+}
+
+// Start runs the reap loop until ctx is cancelled.
+func (r *Reaper) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := r.store.ReapExpiredLeases()
+			if err != nil {
+				log.Printf("rpc: reap expired leases: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("rpc: requeued %d job(s) with expired leases", n)
+			}
+		}
+	}
+}
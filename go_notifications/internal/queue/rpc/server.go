@@ -0,0 +1,221 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/project-anarchy/go_notifications/internal/queue"
+)
+
+// Store is the narrow persistence interface Server depends on, rather than
+// the full *storage.SQLiteStore - the same pattern queue.JobRepo uses.
+type Store interface {
+	LeaseJob(workerID string, tags map[string]string, leaseTTL time.Duration) (id, data string, ok bool, err error)
+	ExtendLease(jobID, workerID string, ttl time.Duration) (bool, error)
+	UpdateJobStatus(jobID, status, errorMsg string) error
+	Notify() <-chan struct{}
+}
+
+// DefaultLeaseTTL is used when the server wasn't configured with a lease
+// TTL; every Next call leases for the server's TTL.
+const DefaultLeaseTTL = 5 * time.Minute
+
+// Server speaks JSON-RPC 2.0 over a WebSocket to remote cmd/agent
+// processes, leasing them jobs from store and tracking their progress.
+// One connection serves one agent at a time, handling its calls
+// synchronously in the order received - an agent only ever has one Next
+// in flight, so there is no need for concurrent request handling per
+// connection.
+type Server struct {
+	store    Store
+	secret   string
+	leaseTTL time.Duration
+
+	// pollFallback bounds how long a blocking Next call waits between
+	// LeaseJob retries when it has missed a wake notification, mirroring
+	// queue.Acquirer's pollFallback.
+	pollFallback time.Duration
+
+	upgrader websocket.Upgrader
+}
+
+// NewServer creates a Server. secret is the shared secret every connecting
+// agent must present; leaseTTL is the default visibility timeout granted
+// to a leased job (see DefaultLeaseTTL).
+func NewServer(store Store, secret string, leaseTTL time.Duration) *Server {
+	if leaseTTL <= 0 {
+		leaseTTL = DefaultLeaseTTL
+	}
+	return &Server{
+		store:        store,
+		secret:       secret,
+		leaseTTL:     leaseTTL,
+		pollFallback: 5 * time.Second,
+		upgrader:     websocket.Upgrader{},
+	}
+}
+
+// ServeHTTP authenticates the connecting agent against a shared secret,
+// upgrades to a WebSocket, and serves JSON-RPC calls on it until the
+// connection closes.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticate(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("rpc: websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	for {
+		var req Request
+		if err := conn.ReadJSON(&req); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("rpc: connection closed: %v", err)
+			}
+			return
+		}
+
+		resp := s.handle(r.Context(), req)
+		if err := conn.WriteJSON(resp); err != nil {
+			log.Printf("rpc: write failed: %v", err)
+			return
+		}
+	}
+}
+
+// authenticate checks the agent's shared secret, accepted either as a
+// Bearer Authorization header or an X-RPC-Secret header, mirroring the
+// multi-source lookup auth.APIKeyAuthenticator uses for the REST API.
+func (s *Server) authenticate(r *http.Request) bool {
+	secret := r.Header.Get("X-RPC-Secret")
+	if secret == "" {
+		auth := r.Header.Get("Authorization")
+		if len(auth) > len("Bearer ") && auth[:len("Bearer ")] == "Bearer " {
+			secret = auth[len("Bearer "):]
+		}
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(s.secret)) == 1
+}
+
+func (s *Server) handle(ctx context.Context, req Request) Response {
+	resp := Response{JSONRPC: "2.0", ID: req.ID}
+
+	var (
+		result interface{}
+		err    error
+	)
+
+	switch req.Method {
+	case MethodNext:
+		result, err = s.handleNext(ctx, req.Params)
+	case MethodUpdate:
+		result, err = s.handleUpdate(req.Params)
+	case MethodLog:
+		result, err = s.handleLog(req.Params)
+	case MethodDone:
+		result, err = s.handleDone(req.Params)
+	case MethodExtend:
+		result, err = s.handleExtend(req.Params)
+	default:
+		resp.Error = &Error{Code: ErrCodeMethodNotFound, Message: "unknown method: " + req.Method}
+		return resp
+	}
+
+	if err != nil {
+		resp.Error = &Error{Code: ErrCodeInternal, Message: err.Error()}
+		return resp
+	}
+
+	raw, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		resp.Error = &Error{Code: ErrCodeInternal, Message: marshalErr.Error()}
+		return resp
+	}
+	resp.Result = raw
+	return resp
+}
+
+// handleNext makes one attempt to lease a job matching params.Tags for
+// params.WorkerID. If none is available it waits for a wake notification,
+// pollFallback, or ctx (the connection's lifetime) to end, whichever comes
+// first, and then returns NextResult{Empty: true} without retrying; the
+// agent calls Next again.
+func (s *Server) handleNext(ctx context.Context, raw json.RawMessage) (*NextResult, error) {
+	var params NextParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid Next params: %w", err)
+	}
+
+	id, data, ok, err := s.store.LeaseJob(params.WorkerID, params.Tags, s.leaseTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		select {
+		case <-ctx.Done():
+			return &NextResult{Empty: true}, nil
+		case <-s.store.Notify():
+		case <-time.After(s.pollFallback):
+		}
+		return &NextResult{Empty: true}, nil
+	}
+
+	var job queue.Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("job %s: %w", id, err)
+	}
+	job.ID = id
+	return &NextResult{Job: &job}, nil
+}
+
+func (s *Server) handleUpdate(raw json.RawMessage) (*struct{}, error) {
+	var params UpdateParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid Update params: %w", err)
+	}
+	return &struct{}{}, s.store.UpdateJobStatus(params.JobID, params.Status, "")
+}
+
+func (s *Server) handleLog(raw json.RawMessage) (*struct{}, error) {
+	var params LogParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid Log params: %w", err)
+	}
+	log.Printf("rpc: job %s: %s", params.JobID, params.Line)
+	return &struct{}{}, nil
+}
+
+func (s *Server) handleDone(raw json.RawMessage) (*struct{}, error) {
+	var params DoneParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid Done params: %w", err)
+	}
+	return &struct{}{}, s.store.UpdateJobStatus(params.JobID, params.Status, params.Error)
+}
+
+func (s *Server) handleExtend(raw json.RawMessage) (*ExtendResult, error) {
+	var params ExtendParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, fmt.Errorf("invalid Extend params: %w", err)
+	}
+	ttl := params.TTL
+	if ttl <= 0 {
+		ttl = s.leaseTTL
+	}
+	ok, err := s.store.ExtendLease(params.JobID, params.WorkerID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &ExtendResult{Ok: ok}, nil
+}
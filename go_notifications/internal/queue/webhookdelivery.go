@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/channels"
+	"github.com/project-anarchy/go_notifications/internal/storage"
+	"github.com/project-anarchy/go_notifications/internal/storage/deliveryrepo"
+)
+
+// WebhookDeliveryConfig configures the durable webhook delivery
+// subsystem's retry backoff and dead-letter threshold.
+type WebhookDeliveryConfig struct {
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	MaxAttempts  int
+	PollInterval time.Duration
+}
+
+// DefaultWebhookDeliveryConfig returns reasonable defaults: a 2s base
+// delay doubling up to 5 minutes, six attempts total before a
+// notification is dead-lettered, polled every 5s.
+func DefaultWebhookDeliveryConfig() WebhookDeliveryConfig {
+	return WebhookDeliveryConfig{
+		BaseDelay:    2 * time.Second,
+		MaxDelay:     5 * time.Minute,
+		MaxAttempts:  6,
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// WebhookDeliveryWorker retries webhook notifications whose synchronous
+// delivery (WebhookChannel.Send, called via Dispatcher.Dispatch) failed,
+// using exponential backoff with jitter, recording every attempt. Once
+// MaxAttempts is exhausted, the notification is moved to the dead-letter
+// table for manual replay via /api/notifications/dead-letter.
+type WebhookDeliveryWorker struct {
+	store      *storage.SQLiteStore
+	dispatcher *channels.Dispatcher
+	cfg        WebhookDeliveryConfig
+}
+
+// NewWebhookDeliveryWorker creates a WebhookDeliveryWorker.
+func NewWebhookDeliveryWorker(store *storage.SQLiteStore, dispatcher *channels.Dispatcher, cfg WebhookDeliveryConfig) *WebhookDeliveryWorker {
+	return &WebhookDeliveryWorker{store: store, dispatcher: dispatcher, cfg: cfg}
+}
+
+// Start polls for due retries until ctx is cancelled.
+func (w *WebhookDeliveryWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.runDueRetries(ctx)
+		}
+	}
+}
+
+func (w *WebhookDeliveryWorker) runDueRetries(ctx context.Context) {
+	due, err := w.store.Deliveries().DueRetries(ctx, time.Now())
+	if err != nil {
+		log.Printf("webhook delivery: list due retries: %v", err)
+		return
+	}
+	for _, retry := range due {
+		w.retryOne(ctx, retry)
+	}
+}
+
+// ScheduleRetry records a failed delivery attempt and schedules the next
+// one, or - once attempts reaches cfg.MaxAttempts - moves n to the
+// dead-letter table instead. Called by the handler that performs the
+// first, synchronous delivery attempt as well as by retryOne.
+func (w *WebhookDeliveryWorker) ScheduleRetry(ctx context.Context, n *channels.Notification, attempts int, attemptErr error) {
+	deliveries := w.store.Deliveries()
+
+	statusCode := 0
+	errMsg := ""
+	if attemptErr != nil {
+		errMsg = attemptErr.Error()
+	}
+	if err := deliveries.RecordAttempt(ctx, n.ID, attempts, statusCode, errMsg); err != nil {
+		log.Printf("webhook delivery: record attempt for notification %d: %v", n.ID, err)
+	}
+
+	if attempts >= w.cfg.MaxAttempts {
+		if err := deliveries.MoveToDeadLetter(ctx, n, attempts, errMsg); err != nil {
+			log.Printf("webhook delivery: dead-letter notification %d: %v", n.ID, err)
+			return
+		}
+		log.Printf("webhook delivery: notification %d dead-lettered after %d attempts", n.ID, attempts)
+		return
+	}
+
+	delay := backoffWithJitter(attempts, w.cfg.BaseDelay, w.cfg.MaxDelay)
+	if err := deliveries.ScheduleRetry(ctx, n.ID, attempts, time.Now().Add(delay), errMsg); err != nil {
+		log.Printf("webhook delivery: schedule retry for notification %d: %v", n.ID, err)
+	}
+}
+
+func (w *WebhookDeliveryWorker) retryOne(ctx context.Context, retry deliveryrepo.Retry) {
+	n, err := w.store.Notifications().Get(ctx, retry.NotificationID)
+	if err != nil {
+		log.Printf("webhook delivery: load notification %d: %v", retry.NotificationID, err)
+		return
+	}
+
+	attempt := retry.Attempts + 1
+	_, dispatchErr := w.dispatcher.Dispatch(n)
+	if dispatchErr == nil {
+		if err := w.store.Deliveries().RecordAttempt(ctx, n.ID, attempt, 0, ""); err != nil {
+			log.Printf("webhook delivery: record attempt for notification %d: %v", n.ID, err)
+		}
+		if err := w.store.Deliveries().ClearRetry(ctx, n.ID); err != nil {
+			log.Printf("webhook delivery: clear retry for notification %d: %v", n.ID, err)
+		}
+		w.store.UpdateStatus(n.ID, "sent", "")
+		return
+	}
+
+	w.ScheduleRetry(ctx, n, attempt, dispatchErr)
+}
+
+// backoffWithJitter returns a delay for the given 1-indexed attempt
+// number: base doubled once per attempt, capped at max, then randomized
+// to between 50% and 100% of that value so many notifications failing at
+// once don't all retry in lockstep.
+func backoffWithJitter(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		base = time.Second
+	}
+	if max <= 0 {
+		max = base
+	}
+
+	shift := attempt - 1
+	if shift < 0 {
+		shift = 0
+	}
+	if shift > 32 {
+		shift = 32
+	}
+	delay := base * time.Duration(uint64(1)<<uint(shift))
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	jitter := time.Duration(rand.Int63n(int64(half) + 1))
+	return half + jitter
+}
@@ -2,21 +2,56 @@
 package queue
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/project-anarchy/go_notifications/internal/channels"
 	"github.com/project-anarchy/go_notifications/internal/storage"
+	"github.com/project-anarchy/go_notifications/internal/storage/jobrepo"
 	"github.com/project-anarchy/go_notifications/internal/templates"
 )
 
+// JobRepo is the narrow persistence interface Worker depends on for status
+// reads, rather than the full *storage.SQLiteStore. Tests can substitute an
+// in-memory fake that satisfies this interface instead of standing up a
+// real database.
+type JobRepo interface {
+	GetStatus(ctx context.Context, id string) (jobrepo.Status, error)
+	UpdateStatus(ctx context.Context, id string, status jobrepo.Status, errMsg string) error
+	// Invalidate evicts id's cached status. Called after a status write that
+	// bypasses UpdateStatus (see Worker.PauseJob/ResumeJob), so a cached
+	// GetStatus doesn't keep returning the pre-write value.
+	Invalidate(id string)
+}
+
+// Job status values recognized by the worker and storage layer. Most jobs
+// only ever move through Pending -> Completed/Failed, but a job can be
+// paused by an operator while it is in flight: it first becomes
+// StatusPauseRequested, gets checkpointed at the next safe boundary, and
+// only then flips to StatusPaused. Resuming moves it to StatusResuming so
+// loadPendingJobs picks it back up without resetting its retry counters.
+const (
+	StatusPending        = "pending"
+	StatusCompleted      = "completed"
+	StatusFailed         = "failed"
+	StatusCancelled      = "cancelled"
+	StatusPaused         = "paused"
+	StatusPauseRequested = "pause-requested"
+	StatusResuming       = "resuming"
+)
+
 // Job represents a queued job
 type Job struct {
 	ID           string                 `json:"id"`
@@ -33,28 +68,164 @@ type Job struct {
 	CallbackURL  string                 `json:"callback_url,omitempty"`
 }
 
+// Job priority tiers. Higher values are dispatched more often: processJobs
+// pulls from these four sub-queues at an 8:4:2:1 ratio so PriorityBackup
+// jobs (long-running maintenance like Backup/Restore) still make progress
+// instead of being starved by a steady stream of PriorityHigh work.
+const (
+	PriorityBackup = 0
+	PriorityLow    = 1
+	PriorityNormal = 2
+	PriorityHigh   = 3
+)
+
+// priorityWeights is the dispatch ratio for each tier, and priorityOrder is
+// the order processJobs checks them in when more than one has budget left
+// in the current round.
+var priorityOrder = []int{PriorityHigh, PriorityNormal, PriorityLow, PriorityBackup}
+
+var priorityWeights = map[int]int{
+	PriorityHigh:   8,
+	PriorityNormal: 4,
+	PriorityLow:    2,
+	PriorityBackup: 1,
+}
+
+// queueTierFor maps an arbitrary Job.Priority to one of the four dispatch
+// tiers, so callers aren't required to use the exported constants exactly.
+func queueTierFor(priority int) int {
+	switch {
+	case priority >= PriorityHigh:
+		return PriorityHigh
+	case priority == PriorityNormal:
+		return PriorityNormal
+	case priority == PriorityLow:
+		return PriorityLow
+	default:
+		return PriorityBackup
+	}
+}
+
 // Worker handles background job processing
 type Worker struct {
 	store      *storage.SQLiteStore
 	dispatcher *channels.Dispatcher
 	renderer   *templates.Renderer
-	jobs       chan *Job
-	queueSize  int64
-	workers    int
-	wg         sync.WaitGroup
+
+	// queues holds one sub-queue per priority tier; processJobs dispatches
+	// across them with weighted fairness instead of strict FIFO.
+	queues              map[int]chan *Job
+	queueSizeByPriority map[int]*int64
+	queueSize           int64
+	workers             int
+	wg                  sync.WaitGroup
+
+	acquirer *Acquirer
+	jobRepo  JobRepo
+
+	pauseMu     sync.Mutex
+	pauseJobIDs map[string]bool
+
+	concurrencyMu       sync.Mutex
+	maxConcurrentByType map[string]int
+	typeSemaphores      map[string]chan struct{}
+
+	backupSink storage.BackupSink
 }
 
 // NewWorker creates a new background worker
 func NewWorker(store *storage.SQLiteStore, dispatcher *channels.Dispatcher, renderer *templates.Renderer, workerCount int) *Worker {
+	queues := make(map[int]chan *Job, len(priorityOrder))
+	sizes := make(map[int]*int64, len(priorityOrder))
+	for _, p := range priorityOrder {
+		queues[p] = make(chan *Job, 250)
+		var n int64
+		sizes[p] = &n
+	}
+
 	return &Worker{
-		store:      store,
-		dispatcher: dispatcher,
-		renderer:   renderer,
-		jobs:       make(chan *Job, 1000),
-		workers:    workerCount,
+		store:               store,
+		dispatcher:          dispatcher,
+		renderer:            renderer,
+		queues:              queues,
+		queueSizeByPriority: sizes,
+		workers:             workerCount,
+		acquirer:            NewAcquirer(store),
+		jobRepo:             store.Jobs(),
+		pauseJobIDs:         make(map[string]bool),
+		maxConcurrentByType: make(map[string]int),
+		typeSemaphores:      make(map[string]chan struct{}),
 	}
 }
 
+// SetMaxConcurrent caps how many jobs of jobType may run at once across all
+// workers, regardless of priority, so a handful of slow jobs (e.g. `shell`)
+// can't occupy every worker goroutine.
+func (w *Worker) SetMaxConcurrent(jobType string, limit int) {
+	w.concurrencyMu.Lock()
+	defer w.concurrencyMu.Unlock()
+
+	w.maxConcurrentByType[jobType] = limit
+	w.typeSemaphores[jobType] = make(chan struct{}, limit)
+}
+
+// SetBackupSink configures where processBackupJob uploads a finished backup
+// artifact (e.g. to S3). A job only uploads if it also sets Data["upload"] =
+// true; without a sink configured, backups are simply left at their
+// configured path.
+func (w *Worker) SetBackupSink(sink storage.BackupSink) {
+	w.backupSink = sink
+}
+
+// acquireTypeSlot blocks until a concurrency slot for job.Type is
+// available, returning a release func. If no limit is configured for the
+// type, it returns immediately with a no-op release.
+func (w *Worker) acquireTypeSlot(ctx context.Context, job *Job) func() {
+	w.concurrencyMu.Lock()
+	sem, limited := w.typeSemaphores[job.Type]
+	w.concurrencyMu.Unlock()
+
+	if !limited {
+		return func() {}
+	}
+
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+	}
+
+	return func() {
+		select {
+		case <-sem:
+		default:
+		}
+	}
+}
+
+// enqueueJob routes job to its priority tier's sub-queue.
+func (w *Worker) enqueueJob(job *Job) error {
+	tier := queueTierFor(job.Priority)
+
+	select {
+	case w.queues[tier] <- job:
+		atomic.AddInt64(&w.queueSize, 1)
+		atomic.AddInt64(w.queueSizeByPriority[tier], 1)
+		return nil
+	default:
+		return fmt.Errorf("queue is full")
+	}
+}
+
+// QueueSizeByPriority reports how many jobs are currently queued at each
+// priority tier, keyed by the PriorityHigh/Normal/Low/Backup constants.
+func (w *Worker) QueueSizeByPriority() map[int]int64 {
+	sizes := make(map[int]int64, len(w.queueSizeByPriority))
+	for p, counter := range w.queueSizeByPriority {
+		sizes[p] = atomic.LoadInt64(counter)
+	}
+	return sizes
+}
+
 // Start begins processing jobs
 func (w *Worker) Start(ctx context.Context) {
 	log.Printf("Starting %d background workers", w.workers)
@@ -65,7 +236,9 @@ func (w *Worker) Start(ctx context.Context) {
 		go w.processJobs(ctx, i)
 	}
 
-	// Start job loader
+	// Start job loader. Unlike a ticker, this blocks on the store's notify
+	// channel and wakes as soon as a job is saved instead of waiting out a
+	// fixed polling interval.
 	go w.loadPendingJobs(ctx)
 
 	w.wg.Wait()
@@ -78,7 +251,7 @@ func (w *Worker) Enqueue(n *channels.Notification) (string, error) {
 		ID:           fmt.Sprintf("job_%d", time.Now().UnixNano()),
 		Type:         "notification",
 		Notification: n,
-		Priority:     0,
+		Priority:     PriorityNormal,
 		MaxRetries:   3,
 		CreatedAt:    time.Now(),
 	}
@@ -88,12 +261,8 @@ func (w *Worker) Enqueue(n *channels.Notification) (string, error) {
 		return "", err
 	}
 
-	// Add to channel
-	select {
-	case w.jobs <- job:
-		atomic.AddInt64(&w.queueSize, 1)
-	default:
-		return "", fmt.Errorf("queue is full")
+	if err := w.enqueueJob(job); err != nil {
+		return "", err
 	}
 
 	return job.ID, nil
@@ -108,7 +277,7 @@ func (w *Worker) EnqueueWithCallback(n *channels.Notification, callback, callbac
 		Notification: n,
 		Callback:     callback,    // TAINT: User-controlled callback command
 		CallbackURL:  callbackURL, // TAINT: User-controlled callback URL (SSRF)
-		Priority:     0,
+		Priority:     PriorityNormal,
 		MaxRetries:   3,
 		CreatedAt:    time.Now(),
 	}
@@ -117,11 +286,8 @@ func (w *Worker) EnqueueWithCallback(n *channels.Notification, callback, callbac
 		return "", err
 	}
 
-	select {
-	case w.jobs <- job:
-		atomic.AddInt64(&w.queueSize, 1)
-	default:
-		return "", fmt.Errorf("queue is full")
+	if err := w.enqueueJob(job); err != nil {
+		return "", err
 	}
 
 	return job.ID, nil
@@ -132,28 +298,84 @@ func (w *Worker) QueueSize() int64 {
 	return atomic.LoadInt64(&w.queueSize)
 }
 
-// processJobs is the main worker loop
+// processJobs is the main worker loop. It dequeues jobs across the four
+// priority tiers with an 8:4:2:1 weighted-fair schedule instead of strict
+// FIFO, so PriorityBackup work still gets a turn under sustained
+// PriorityHigh load.
 func (w *Worker) processJobs(ctx context.Context, workerID int) {
 	defer w.wg.Done()
 
 	log.Printf("Worker %d started", workerID)
 
+	tokens := make(map[int]int, len(priorityWeights))
+	refillTokens(tokens)
+
 	for {
-		select {
-		case <-ctx.Done():
+		job, tier, ok := w.dequeue(ctx, tokens)
+		if !ok {
 			log.Printf("Worker %d stopping", workerID)
 			return
-		case job := <-w.jobs:
-			atomic.AddInt64(&w.queueSize, -1)
-			w.processJob(job)
 		}
+
+		atomic.AddInt64(&w.queueSize, -1)
+		atomic.AddInt64(w.queueSizeByPriority[tier], -1)
+		w.processJob(job)
 	}
 }
 
-// processJob handles a single job
+func refillTokens(tokens map[int]int) {
+	for p, weight := range priorityWeights {
+		tokens[p] = weight
+	}
+}
+
+// dequeue picks the next job to run, preferring priority tiers that still
+// have dispatch budget this round. When every tier with budget left is
+// empty, it refills the budget and falls back to a blocking select across
+// all tiers so a worker never busy-spins.
+func (w *Worker) dequeue(ctx context.Context, tokens map[int]int) (*Job, int, bool) {
+	for {
+		for _, tier := range priorityOrder {
+			if tokens[tier] <= 0 {
+				continue
+			}
+			select {
+			case job := <-w.queues[tier]:
+				tokens[tier]--
+				return job, tier, true
+			default:
+			}
+		}
+
+		refillTokens(tokens)
+
+		select {
+		case <-ctx.Done():
+			return nil, 0, false
+		case job := <-w.queues[PriorityHigh]:
+			tokens[PriorityHigh]--
+			return job, PriorityHigh, true
+		case job := <-w.queues[PriorityNormal]:
+			tokens[PriorityNormal]--
+			return job, PriorityNormal, true
+		case job := <-w.queues[PriorityLow]:
+			tokens[PriorityLow]--
+			return job, PriorityLow, true
+		case job := <-w.queues[PriorityBackup]:
+			tokens[PriorityBackup]--
+			return job, PriorityBackup, true
+		}
+	}
+}
+
+// processJob handles a single job, respecting any MaxConcurrentByType limit
+// configured via SetMaxConcurrent before it actually runs.
 func (w *Worker) processJob(job *Job) {
 	log.Printf("Processing job %s (type: %s)", job.ID, job.Type)
 
+	release := w.acquireTypeSlot(context.Background(), job)
+	defer release()
+
 	var err error
 
 	switch job.Type {
@@ -166,10 +388,21 @@ func (w *Worker) processJob(job *Job) {
 		err = w.processShellJob(job)
 	case "webhook":
 		err = w.processWebhookJob(job)
+	case "backup":
+		err = w.processBackupJob(job)
+	case "restore":
+		err = w.processRestoreJob(job)
 	default:
 		err = fmt.Errorf("unknown job type: %s", job.Type)
 	}
 
+	// Checkpoint boundary: a job that was requested to pause while it was
+	// executing is parked here instead of being marked completed/failed,
+	// so its retry counters and scheduled time are preserved as-is.
+	if w.checkpointIfPauseRequested(job) {
+		return
+	}
+
 	if err != nil {
 		log.Printf("Job %s failed: %v", job.ID, err)
 		w.handleFailure(job, err)
@@ -179,6 +412,101 @@ func (w *Worker) processJob(job *Job) {
 	}
 }
 
+// checkpointIfPauseRequested flips a job to StatusPaused if it was marked
+// pause-requested while mid-execution, and reports whether it did so. It is
+// called at the safe boundaries documented on PauseJob: after a job finishes
+// running but before handleSuccess/handleFailure, and between retry attempts.
+func (w *Worker) checkpointIfPauseRequested(job *Job) bool {
+	w.pauseMu.Lock()
+	requested := w.pauseJobIDs[job.ID]
+	w.pauseMu.Unlock()
+
+	if !requested {
+		return false
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		log.Printf("Failed to checkpoint job %s before pausing: %v", job.ID, err)
+		return false
+	}
+
+	if err := w.store.UpdateJobData(job.ID, string(data)); err != nil {
+		log.Printf("Failed to persist checkpoint for job %s: %v", job.ID, err)
+	}
+	if err := w.jobRepo.UpdateStatus(context.Background(), job.ID, jobrepo.Status(StatusPaused), ""); err != nil {
+		log.Printf("Failed to update status for job %s: %v", job.ID, err)
+	}
+
+	w.pauseMu.Lock()
+	delete(w.pauseJobIDs, job.ID)
+	w.pauseMu.Unlock()
+
+	log.Printf("Job %s paused at checkpoint (retries=%d)", job.ID, job.Retries)
+	return true
+}
+
+// PauseJob requests that a job stop at its next safe boundary. If the job is
+// currently mid-execution (leased by a worker) it transitions to
+// StatusPauseRequested and is checkpointed (preserving Retries and
+// ScheduledAt) the next time it reaches a boundary; if it is only queued, it
+// is paused immediately. Returns an error, leaving the job untouched, if
+// jobID isn't in a pausable state (e.g. already completed/failed/paused).
+func (w *Worker) PauseJob(jobID string) error {
+	w.pauseMu.Lock()
+	w.pauseJobIDs[jobID] = true
+	w.pauseMu.Unlock()
+
+	ok, err := w.store.PauseJob(jobID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		w.pauseMu.Lock()
+		delete(w.pauseJobIDs, jobID)
+		w.pauseMu.Unlock()
+		return fmt.Errorf("job %s is not in a pausable state", jobID)
+	}
+	// PauseJob writes through the store directly (its transition depends on
+	// the job's current status), bypassing jobRepo's cache - invalidate so a
+	// cached GetStatus doesn't keep returning the pre-pause value.
+	w.jobRepo.Invalidate(jobID)
+	return nil
+}
+
+// ResumeJob re-enqueues a single paused job without resetting its retry
+// counters. Returns an error, leaving the job untouched, if jobID isn't
+// currently StatusPaused.
+func (w *Worker) ResumeJob(jobID string) error {
+	w.pauseMu.Lock()
+	delete(w.pauseJobIDs, jobID)
+	w.pauseMu.Unlock()
+
+	ok, err := w.store.ResumeJob(jobID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("job %s is not paused", jobID)
+	}
+	w.jobRepo.Invalidate(jobID)
+	return nil
+}
+
+// PauseQueue pauses every job of the given type that is still pending or
+// resuming in the jobs table. Jobs of that type already acquired by a
+// worker, whether waiting in memory or executing, are not marked and run
+// as usual.
+func (w *Worker) PauseQueue(jobType string) (int64, error) {
+	return w.store.PauseJobsByType(jobType)
+}
+
+// ResumeQueue re-enqueues every paused job of the given type without
+// resetting retry counters.
+func (w *Worker) ResumeQueue(jobType string) (int64, error) {
+	return w.store.ResumeJobsByType(jobType)
+}
+
 // processNotification sends a notification
 func (w *Worker) processNotification(job *Job) error {
 	// Render template if specified
@@ -265,6 +593,203 @@ func (w *Worker) processWebhookJob(job *Job) error {
 	return err
 }
 
+// processBackupJob runs an online backup of the live database using
+// SQLite's page-by-page backup API (storage.SQLiteStore.BackupTo), so the
+// source stays available for writes throughout. Job Data fields:
+//
+//	path          (string, required) destination path for the backup
+//	compress      (bool)             gzip the finished artifact
+//	keep_last_n   (number)           prune older backups matching path's
+//	                                 base name, keeping only the N newest
+//	upload        (bool)             hand the finished artifact to the
+//	                                 Worker's BackupSink, if one is set
+//
+// Progress is written back into job.Data as pages_remaining/pages_total and
+// saved to the job's stored data on every step. GetJobStatus returns only
+// the job's status, not these fields.
+func (w *Worker) processBackupJob(job *Job) error {
+	path, ok := job.Data["path"].(string)
+	if !ok || path == "" {
+		return fmt.Errorf("backup job: path not specified")
+	}
+	compress, _ := job.Data["compress"].(bool)
+	upload, _ := job.Data["upload"].(bool)
+	keepLastN := intFromData(job.Data, "keep_last_n")
+
+	rawPath := path
+	if compress {
+		rawPath = path + ".tmp"
+	}
+
+	ctx := context.Background()
+	err := w.store.BackupTo(ctx, rawPath, func(p storage.BackupProgress) {
+		job.Data["pages_remaining"] = p.PagesRemaining
+		job.Data["pages_total"] = p.PagesTotal
+		if data, err := json.Marshal(job); err == nil {
+			w.store.UpdateJobData(job.ID, string(data))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+
+	finalPath := path
+	if compress {
+		finalPath = path + ".gz"
+		if err := gzipFile(rawPath, finalPath); err != nil {
+			return fmt.Errorf("compress backup: %w", err)
+		}
+		os.Remove(rawPath)
+	}
+
+	if keepLastN > 0 {
+		if err := pruneBackups(finalPath, keepLastN); err != nil {
+			log.Printf("Backup job %s: retention prune failed: %v", job.ID, err)
+		}
+	}
+
+	if upload && w.backupSink == nil {
+		log.Printf("Backup job %s: upload requested but no BackupSink is configured; backup left at %s", job.ID, finalPath)
+	} else if upload {
+		f, err := os.Open(finalPath)
+		if err != nil {
+			return fmt.Errorf("open backup artifact for upload: %w", err)
+		}
+		defer f.Close()
+
+		if err := w.backupSink.Write(f); err != nil {
+			return fmt.Errorf("upload backup artifact: %w", err)
+		}
+	}
+
+	job.Data["result_path"] = finalPath
+	return nil
+}
+
+// processRestoreJob restores the live database from a backup artifact
+// produced by processBackupJob, decompressing it first if it is gzipped.
+// storage.SQLiteStore.RestoreFrom verifies the artifact with PRAGMA
+// integrity_check before swapping it in.
+func (w *Worker) processRestoreJob(job *Job) error {
+	path, ok := job.Data["path"].(string)
+	if !ok || path == "" {
+		return fmt.Errorf("restore job: path not specified")
+	}
+
+	restorePath := path
+	if filepath.Ext(path) == ".gz" {
+		tmp := strings.TrimSuffix(path, ".gz") + ".restore-tmp"
+		if err := gunzipFile(path, tmp); err != nil {
+			return fmt.Errorf("decompress backup: %w", err)
+		}
+		defer os.Remove(tmp)
+		restorePath = tmp
+	}
+
+	return w.store.RestoreFrom(context.Background(), restorePath)
+}
+
+// intFromData reads an integer-valued field out of a job's Data map. Values
+// decoded from JSON arrive as float64, so both forms are accepted.
+func intFromData(data map[string]interface{}, key string) int {
+	switch v := data[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+// pruneBackups keeps only the keepLastN newest backups that share newest's
+// base name pattern (path with any trailing numeric/timestamp suffix
+// ignored), deleting the rest.
+func pruneBackups(newest string, keepLastN int) error {
+	dir := filepath.Dir(newest)
+	pattern := filepath.Base(newest)
+	if ext := filepath.Ext(pattern); ext != "" {
+		pattern = strings.TrimSuffix(pattern, ext) + "*" + ext
+	} else {
+		pattern += "*"
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return err
+	}
+	if len(matches) <= keepLastN {
+		return nil
+	}
+
+	infos := make([]os.FileInfo, 0, len(matches))
+	byName := make(map[string]os.FileInfo, len(matches))
+	for _, m := range matches {
+		fi, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, fi)
+		byName[fi.Name()] = fi
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().After(infos[j].ModTime())
+	})
+
+	for _, fi := range infos[keepLastN:] {
+		if err := os.Remove(filepath.Join(dir, fi.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gw := gzip.NewWriter(out)
+	if _, err := io.Copy(gw, in); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+func gunzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	gr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, gr); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
 // handleSuccess handles successful job completion
 func (w *Worker) handleSuccess(job *Job) {
 	// Update job status
@@ -287,6 +812,12 @@ func (w *Worker) handleSuccess(job *Job) {
 func (w *Worker) handleFailure(job *Job, jobErr error) {
 	job.Retries++
 
+	// Checkpoint boundary: between retries, before scheduling the next
+	// attempt.
+	if w.checkpointIfPauseRequested(job) {
+		return
+	}
+
 	if job.Retries < job.MaxRetries {
 		// Retry with exponential backoff
 		delay := time.Duration(job.Retries*job.Retries) * time.Second
@@ -295,8 +826,7 @@ func (w *Worker) handleFailure(job *Job, jobErr error) {
 		log.Printf("Scheduling retry %d for job %s in %v", job.Retries, job.ID, delay)
 
 		time.AfterFunc(delay, func() {
-			w.jobs <- job
-			atomic.AddInt64(&w.queueSize, 1)
+			w.enqueueJob(job)
 		})
 	} else {
 		// Max retries exceeded
@@ -310,52 +840,46 @@ func (w *Worker) handleFailure(job *Job, jobErr error) {
 	}
 }
 
-// persistJob saves job to database
+// persistJob saves job to database, recording its priority in its own
+// column so LoadPendingJobs/AcquireJob can order by it cheaply.
 func (w *Worker) persistJob(job *Job) error {
 	data, err := json.Marshal(job)
 	if err != nil {
 		return err
 	}
 
-	return w.store.SaveJob(job.ID, string(data))
+	return w.store.SaveJobWithPriority(job.ID, string(data), job.Priority)
 }
 
-// loadPendingJobs loads pending jobs from storage
+// loadPendingJobs feeds the worker's priority sub-queues by acquiring one
+// job at a time from the Acquirer, which blocks on the store's notify
+// channel rather than polling on a fixed interval. It claims jobs under a
+// synthetic "loader" worker ID and routes each into its priority tier.
 func (w *Worker) loadPendingJobs(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
 	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			jobs, err := w.store.LoadPendingJobs()
-			if err != nil {
-				log.Printf("Error loading pending jobs: %v", err)
-				continue
+		job, err := w.acquirer.AcquireJob(ctx, "loader", nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
 			}
+			log.Printf("Error acquiring pending job: %v", err)
+			continue
+		}
 
-			for _, jobData := range jobs {
-				var job Job
-				if err := json.Unmarshal([]byte(jobData), &job); err != nil {
-					continue
-				}
-
-				select {
-				case w.jobs <- &job:
-					atomic.AddInt64(&w.queueSize, 1)
-				default:
-					// Queue full
-				}
-			}
+		if err := w.enqueueJob(job); err != nil {
+			log.Printf("Dropping acquired job %s: %v", job.ID, err)
+		}
+
+		if ctx.Err() != nil {
+			return
 		}
 	}
 }
 
-// updateJobStatus updates job status in storage
-func (w *Worker) updateJobStatus(jobID, status, error string) {
-	w.store.UpdateJobStatus(jobID, status, error)
+// updateJobStatus updates job status via jobRepo, so its cache is kept in
+// sync with every write instead of only ever being populated by reads.
+func (w *Worker) updateJobStatus(jobID, status, error string) error {
+	return w.jobRepo.UpdateStatus(context.Background(), jobID, jobrepo.Status(status), error)
 }
 
 // ScheduleJob schedules a job for future execution
@@ -371,12 +895,12 @@ func (w *Worker) ScheduleJob(job *Job, runAt time.Time) (string, error) {
 	delay := time.Until(runAt)
 	if delay > 0 {
 		time.AfterFunc(delay, func() {
-			w.jobs <- job
-			atomic.AddInt64(&w.queueSize, 1)
+			w.enqueueJob(job)
 		})
 	} else {
-		w.jobs <- job
-		atomic.AddInt64(&w.queueSize, 1)
+		if err := w.enqueueJob(job); err != nil {
+			return "", err
+		}
 	}
 
 	return job.ID, nil
@@ -387,9 +911,11 @@ func (w *Worker) CancelJob(jobID string) error {
 	return w.updateJobStatus(jobID, "cancelled", "")
 }
 
-// GetJobStatus returns job status
+// GetJobStatus returns job status, served from JobRepo's LRU cache for
+// callers (like /api/jobs/{id}) that poll the same job repeatedly.
 func (w *Worker) GetJobStatus(jobID string) (string, error) {
-	return w.store.GetJobStatus(jobID)
+	status, err := w.jobRepo.GetStatus(context.Background(), jobID)
+	return string(status), err
 }
 
 func timePtr(t time.Time) *time.Time {
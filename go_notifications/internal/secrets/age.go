@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// ageHKDFInfo matches age's own X25519 recipient stanza info string, so a
+// deployment already holding age-format X25519 identities/ephemeral
+// shares can reuse them here.
+const ageHKDFInfo = "age-encryption.org/v1/X25519"
+
+// AgeX25519KeyProvider unwraps the KEK using an X25519 (age-style)
+// identity: IdentityPath holds this deployment's 32-byte X25519 private
+// key (base64), and EphemeralPublicKey + WrappedKey (both base64,
+// produced once when the config was encrypted) carry the per-file
+// ephemeral share and the KEK it wraps - the same shape age itself uses
+// for its "X25519" recipient stanza, simplified to a single recipient
+// and without the bech32/armor framing.
+type AgeX25519KeyProvider struct {
+	IdentityPath       string
+	EphemeralPublicKey string
+	WrappedKey         string
+}
+
+// Key implements KeyProvider.
+func (p AgeX25519KeyProvider) Key() ([]byte, error) {
+	identity, err := p.readIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPub, err := decodeBase64("ephemeral public key", p.EphemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(ephemeralPub) != curve25519.PointSize {
+		return nil, fmt.Errorf("secrets: age ephemeral public key must be %d bytes", curve25519.PointSize)
+	}
+
+	sharedSecret, err := curve25519.X25519(identity, ephemeralPub)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: age X25519 exchange: %w", err)
+	}
+
+	identityPub, err := curve25519.X25519(identity, curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: deriving age identity public key: %w", err)
+	}
+
+	wrappingKey, err := ageHKDF(sharedSecret, append(append([]byte{}, ephemeralPub...), identityPub...))
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := decodeBase64("wrapped key", p.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < 12 {
+		return nil, fmt.Errorf("secrets: age wrapped key is truncated")
+	}
+	nonce, ciphertext := wrapped[:12], wrapped[12:]
+
+	kek, err := aesGCMOpen(wrappingKey, nonce, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: unwrapping age-wrapped KEK: %w", err)
+	}
+	if len(kek) != 32 {
+		return nil, fmt.Errorf("secrets: age-wrapped KEK must be 32 bytes, got %d", len(kek))
+	}
+	return kek, nil
+}
+
+func (p AgeX25519KeyProvider) readIdentity() ([]byte, error) {
+	data, err := os.ReadFile(p.IdentityPath)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: reading age identity %q: %w", p.IdentityPath, err)
+	}
+	identity, err := decodeBase64("age identity", strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	if len(identity) != curve25519.ScalarSize {
+		return nil, fmt.Errorf("secrets: age identity must be %d bytes", curve25519.ScalarSize)
+	}
+	return identity, nil
+}
+
+// ageHKDF derives a 32-byte key from secret and salt using HKDF-SHA256
+// with info ageHKDFInfo, matching age's own KDF (RFC 5869, inlined here
+// rather than pulling in golang.org/x/crypto/hkdf for a single call).
+func ageHKDF(secret, salt []byte) ([]byte, error) {
+	extractor := hmac.New(sha256.New, salt)
+	if _, err := extractor.Write(secret); err != nil {
+		return nil, err
+	}
+	prk := extractor.Sum(nil)
+
+	expander := hmac.New(sha256.New, prk)
+	if _, err := io.WriteString(expander, ageHKDFInfo); err != nil {
+		return nil, err
+	}
+	if _, err := expander.Write([]byte{0x01}); err != nil {
+		return nil, err
+	}
+	return expander.Sum(nil)[:32], nil
+}
+
+func decodeBase64(label, encoded string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %s is not valid base64: %w", label, err)
+	}
+	return b, nil
+}
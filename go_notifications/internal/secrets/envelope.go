@@ -0,0 +1,124 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// Encrypt envelope-encrypts plaintext under a random, per-value 32-byte
+// data encryption key (DEK): the DEK itself is wrapped with kek (the
+// KeyProvider-supplied key-encryption key) rather than using kek to
+// encrypt plaintext directly, so rotating kek only requires re-wrapping
+// the DEK, not re-encrypting every value. The result is the base64 of
+// nonce1, wrappedDEK, nonce2 and ciphertext, in that order, each preceded
+// by its 4-byte big-endian length - the form stored on disk for a
+// "!enc"-tagged YAML value.
+func Encrypt(plaintext, kek []byte) (string, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", err
+	}
+
+	wrapNonce, wrappedDEK, err := aesGCMSeal(kek, dek)
+	if err != nil {
+		return "", fmt.Errorf("secrets: wrapping DEK: %w", err)
+	}
+
+	dataNonce, ciphertext, err := aesGCMSeal(dek, plaintext)
+	if err != nil {
+		return "", fmt.Errorf("secrets: encrypting value: %w", err)
+	}
+
+	out := make([]byte, 0, len(wrapNonce)+len(wrappedDEK)+len(dataNonce)+len(ciphertext)+8)
+	out = appendLenPrefixed(out, wrapNonce)
+	out = appendLenPrefixed(out, wrappedDEK)
+	out = appendLenPrefixed(out, dataNonce)
+	out = appendLenPrefixed(out, ciphertext)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+// Decrypt reverses Encrypt, unwrapping the envelope's DEK with kek before
+// decrypting the value itself.
+func Decrypt(envelope string, kek []byte) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: malformed envelope: %w", err)
+	}
+
+	wrapNonce, raw, err := readLenPrefixed(raw)
+	if err != nil {
+		return nil, err
+	}
+	wrappedDEK, raw, err := readLenPrefixed(raw)
+	if err != nil {
+		return nil, err
+	}
+	dataNonce, raw, err := readLenPrefixed(raw)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, _, err := readLenPrefixed(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := aesGCMOpen(kek, wrapNonce, wrappedDEK)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: unwrapping DEK: %w", err)
+	}
+
+	plaintext, err := aesGCMOpen(dek, dataNonce, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: decrypting value: %w", err)
+	}
+	return plaintext, nil
+}
+
+func aesGCMSeal(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+func aesGCMOpen(key, nonce, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func appendLenPrefixed(out, b []byte) []byte {
+	n := len(b)
+	out = append(out, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	return append(out, b...)
+}
+
+func readLenPrefixed(b []byte) (value, rest []byte, err error) {
+	if len(b) < 4 {
+		return nil, nil, fmt.Errorf("secrets: truncated envelope")
+	}
+	n := int(b[0])<<24 | int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+	b = b[4:]
+	if len(b) < n {
+		return nil, nil, fmt.Errorf("secrets: truncated envelope")
+	}
+	return b[:n], b[n:], nil
+}
@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/project-anarchy/go_notifications/pkg/safehttp"
+)
+
+// KMSKeyProvider fetches the KEK from an external KMS HTTP endpoint on
+// every Key call, through a safehttp.Client so the configured URL can't
+// be pointed at an internal address. The endpoint is expected to answer
+// GET with {"key": "<base64 32 bytes>"}.
+type KMSKeyProvider struct {
+	URL    string
+	Bearer string
+
+	client *safehttp.Client
+}
+
+// NewKMSKeyProvider creates a KMSKeyProvider for url, authenticating with
+// bearer (empty disables the Authorization header).
+func NewKMSKeyProvider(url, bearer string) *KMSKeyProvider {
+	return &KMSKeyProvider{
+		URL:    url,
+		Bearer: bearer,
+		client: safehttp.NewClient(safehttp.DefaultPolicy("kms")),
+	}
+}
+
+type kmsResponse struct {
+	Key string `json:"key"`
+}
+
+// Key implements KeyProvider.
+func (p *KMSKeyProvider) Key() ([]byte, error) {
+	req, err := newGetRequest(p.URL, p.Bearer)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: fetching KEK from KMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("secrets: KMS returned status %d", resp.StatusCode)
+	}
+
+	var parsed kmsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("secrets: decoding KMS response: %w", err)
+	}
+	return decodeKey(parsed.Key)
+}
+
+func newGetRequest(url, bearer string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: building KMS request: %w", err)
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return req, nil
+}
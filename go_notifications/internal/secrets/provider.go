@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider resolves the 32-byte key-encryption key (KEK) used to
+// unwrap a SecretString's envelope (see Decrypt). Key may be called once
+// per Resolve, so an implementation backed by a network call (KMSKeyProvider)
+// should cache internally if that matters.
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// EnvKeyProvider reads a base64-encoded 32-byte KEK from an environment
+// variable - the simplest provider, suited to a KEK injected by the
+// deployment platform's own secret store (e.g. a Kubernetes Secret
+// mounted as an env var).
+type EnvKeyProvider struct {
+	Var string
+}
+
+// Key implements KeyProvider.
+func (p EnvKeyProvider) Key() ([]byte, error) {
+	val := os.Getenv(p.Var)
+	if val == "" {
+		return nil, fmt.Errorf("secrets: environment variable %q is not set", p.Var)
+	}
+	return decodeKey(val)
+}
+
+// FileKeyProvider reads a base64-encoded 32-byte KEK from a file -
+// suited to a KEK mounted from a platform secret volume rather than an
+// env var (e.g. so it never shows up in `ps`/process environment dumps).
+type FileKeyProvider struct {
+	Path string
+}
+
+// Key implements KeyProvider.
+func (p FileKeyProvider) Key() ([]byte, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: reading key file %q: %w", p.Path, err)
+	}
+	return decodeKey(strings.TrimSpace(string(data)))
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: key material is not valid base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secrets: key material must decode to 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
@@ -0,0 +1,39 @@
+package secrets
+
+import "fmt"
+
+// Resolve decrypts s in place if it's ciphertext (Encrypted()), or
+// accepts it as-is if it's plaintext and allowPlaintext is true.
+// Plaintext with allowPlaintext false is refused outright - the
+// "--allow-plaintext-secrets" escape hatch config.Load requires a
+// caller to opt into explicitly. An empty, unset field is never an
+// error: Reveal() on it just returns "".
+func Resolve(s *SecretString, provider KeyProvider, allowPlaintext bool) error {
+	if s.Settled() || s.IsZero() {
+		return nil
+	}
+
+	if !s.Encrypted() {
+		if !allowPlaintext {
+			return fmt.Errorf("secrets: plaintext value found for a secret field; pass --allow-plaintext-secrets to allow this, or encrypt it with a \"!enc\" tag")
+		}
+		s.Resolve(s.Raw())
+		return nil
+	}
+
+	if provider == nil {
+		return fmt.Errorf("secrets: config has an encrypted (\"!enc\") value but no key provider is configured")
+	}
+
+	kek, err := provider.Key()
+	if err != nil {
+		return fmt.Errorf("secrets: loading KEK: %w", err)
+	}
+
+	plaintext, err := Decrypt(s.Raw(), kek)
+	if err != nil {
+		return err
+	}
+	s.Resolve(string(plaintext))
+	return nil
+}
@@ -0,0 +1,79 @@
+// Package secrets provides envelope-encrypted configuration values:
+// SecretString, decrypted lazily from a KeyProvider-supplied KEK, and the
+// Encrypt/Decrypt envelope format it's stored in on disk.
+package secrets
+
+import "gopkg.in/yaml.v3"
+
+// SecretString holds a configuration value that may be plaintext or
+// envelope-ciphertext on disk (see Encrypt/Decrypt). Resolve - called
+// once by config.Load, after its KeyProvider is built - decrypts it (or
+// validates it's allowed to stay plaintext) into the in-memory value
+// Reveal returns. String and MarshalJSON deliberately never return that
+// value, so a stray log.Printf("%v", cfg) or an accidental
+// json.Marshal(cfg) can't leak it.
+type SecretString struct {
+	raw       string
+	encrypted bool
+	resolved  string
+	settled   bool
+}
+
+// UnmarshalYAML captures the node's literal value and whether it carries
+// the "!enc" tag (ciphertext, see Encrypt) - actual decryption is
+// deferred to Resolve, since the KeyProvider config.Load builds it with
+// isn't known yet at unmarshal time.
+func (s *SecretString) UnmarshalYAML(node *yaml.Node) error {
+	s.raw = node.Value
+	s.encrypted = node.Tag == "!enc"
+	return nil
+}
+
+// Raw returns the value as read from YAML - ciphertext if Encrypted,
+// plaintext otherwise. Used by config.Load to decide whether to decrypt
+// or to enforce --allow-plaintext-secrets.
+func (s *SecretString) Raw() string { return s.raw }
+
+// Encrypted reports whether the on-disk value was tagged "!enc".
+func (s *SecretString) Encrypted() bool { return s.encrypted }
+
+// Resolve records value (the plaintext, whether decrypted or read as-is)
+// as what Reveal returns.
+func (s *SecretString) Resolve(value string) {
+	s.resolved = value
+	s.settled = true
+}
+
+// Reveal returns the resolved plaintext value, for the SMTP/webhook/auth
+// clients that actually need it.
+func (s SecretString) Reveal() string { return s.resolved }
+
+// IsZero reports whether no value was ever set (empty config field).
+func (s SecretString) IsZero() bool { return s.raw == "" }
+
+// Settled reports whether Resolve (or Plain) has already supplied this
+// value's plaintext, so the package-level Resolve function can skip an
+// already-settled field (e.g. one Load set via an env var override)
+// instead of re-running it through the "!enc"/allow-plaintext gate.
+func (s SecretString) Settled() bool { return s.settled }
+
+// Plain builds an already-resolved SecretString directly from value,
+// bypassing Resolve/the plaintext-secrets gate entirely. For values that
+// are inherently plaintext at the point they reach Config - e.g. an
+// env var override a deployment's orchestrator injected at runtime -
+// not for values read out of the YAML file itself.
+func Plain(value string) SecretString {
+	return SecretString{raw: value, resolved: value, settled: true}
+}
+
+// String always returns "***", regardless of whether a value is set, so
+// fmt.Stringer callers (log.Printf("%v", ...), %+v on a containing
+// struct) never print the secret.
+func (s SecretString) String() string { return "***" }
+
+// MarshalJSON always marshals to "***", so an accidental
+// json.Marshal(cfg) (e.g. ImportConfig/ExportConfig handlers) never
+// leaks the value either.
+func (s SecretString) MarshalJSON() ([]byte, error) {
+	return []byte(`"***"`), nil
+}
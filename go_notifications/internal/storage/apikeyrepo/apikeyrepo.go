@@ -0,0 +1,86 @@
+// Package apikeyrepo provides typed, parameterized access to the api_keys
+// table: every currently-active hashed API key an auth.APIKeyAuthenticator
+// may accept, keyed by an opaque key ID rather than the key itself so
+// several keys can be active at once for rotation (see Rotate/Revoke).
+package apikeyrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Key is the typed row shape for the api_keys table. Hash is the hex
+// SHA-256 digest of the raw key - the raw key itself is never stored, so
+// a database read can't recover it.
+type Key struct {
+	ID        string
+	Hash      string
+	Roles     []string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// Repo provides typed access to the api_keys table.
+type Repo struct {
+	db *sql.DB
+}
+
+// New creates a Repo backed by db.
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Rotate activates a key under id with hash and roles, replacing whatever
+// was previously stored for that id and clearing any revocation - the same
+// "insert or update, no history" shape as callbackrepo.Rotate. A caller
+// rotating a key typically picks a fresh id rather than reusing one, so
+// the old key stays valid (and revocable independently) until it's
+// explicitly retired with Revoke.
+func (r *Repo) Rotate(ctx context.Context, id, hash string, roles []string) error {
+	rolesJSON, err := json.Marshal(roles)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (id, hash, roles, created_at, revoked_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP, NULL)
+		ON CONFLICT(id) DO UPDATE SET hash = excluded.hash, roles = excluded.roles, revoked_at = NULL
+	`, id, hash, string(rolesJSON))
+	return err
+}
+
+// Revoke marks id's key as revoked as of now, without deleting the row -
+// ListActive excludes it until a later Rotate under the same id clears
+// the revocation.
+func (r *Repo) Revoke(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	return err
+}
+
+// ListActive returns every key with no revoked_at, the set an
+// auth.APIKeyAuthenticator should accept a presented key's hash against.
+func (r *Repo) ListActive(ctx context.Context) ([]Key, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, hash, roles, created_at, revoked_at FROM api_keys WHERE revoked_at IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		var k Key
+		var rolesJSON string
+		if err := rows.Scan(&k.ID, &k.Hash, &rolesJSON, &k.CreatedAt, &k.RevokedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(rolesJSON), &k.Roles); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,73 @@
+// Package callbackrepo provides typed, parameterized access to the
+// callback_secrets table: the per-callback-ID signing secret ProcessCallback
+// verifies inbound requests against, replacing the old static
+// Config.Callbacks map.
+package callbackrepo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Secret is the typed row shape for the callback_secrets table.
+type Secret struct {
+	CallbackID string
+	Secret     string
+	RotatedAt  time.Time
+}
+
+// Repo provides typed access to the callback_secrets table.
+type Repo struct {
+	db *sql.DB
+}
+
+// New creates a Repo backed by db.
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Rotate stores secret as callbackID's current signing secret, replacing
+// whatever was there before. There is deliberately no history of prior
+// secrets: a caller that needs overlap during rotation should keep the old
+// secret valid downstream until every sender has switched to the new one.
+func (r *Repo) Rotate(ctx context.Context, callbackID, secret string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO callback_secrets (callback_id, secret, rotated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(callback_id) DO UPDATE SET secret = excluded.secret, rotated_at = excluded.rotated_at
+	`, callbackID, secret)
+	return err
+}
+
+// Get returns callbackID's current signing secret, or sql.ErrNoRows if none
+// has been set.
+func (r *Repo) Get(ctx context.Context, callbackID string) (*Secret, error) {
+	var s Secret
+	err := r.db.QueryRowContext(ctx, `
+		SELECT callback_id, secret, rotated_at FROM callback_secrets WHERE callback_id = ?
+	`, callbackID).Scan(&s.CallbackID, &s.Secret, &s.RotatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// List returns every callback ID with a registered secret, alphabetically.
+func (r *Repo) List(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT callback_id FROM callback_secrets ORDER BY callback_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
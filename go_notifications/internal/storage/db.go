@@ -5,17 +5,56 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os/exec"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/project-anarchy/go_notifications/internal/channels"
+	"github.com/project-anarchy/go_notifications/internal/storage/apikeyrepo"
+	"github.com/project-anarchy/go_notifications/internal/storage/callbackrepo"
+	"github.com/project-anarchy/go_notifications/internal/storage/deliveryrepo"
+	"github.com/project-anarchy/go_notifications/internal/storage/jobrepo"
+	"github.com/project-anarchy/go_notifications/internal/storage/notificationrepo"
+	"github.com/project-anarchy/go_notifications/internal/storage/templaterepo"
+	"github.com/project-anarchy/go_notifications/internal/storage/userrepo"
 )
 
 // SQLiteStore handles SQLite database operations
 type SQLiteStore struct {
-	db *sql.DB
+	dbPath string
+
+	// notify is fired (non-blocking, best-effort) whenever a job is saved
+	// so a queue.Acquirer can wake up immediately instead of polling.
+	notify chan struct{}
+
+	// mu guards db, jobs, notifications, users, and templates, the fields
+	// RestoreFrom reassigns wholesale after swapping in a restored database
+	// file. Every handler/worker goroutine reads these concurrently via
+	// conn() and the typed-repo accessors below, so a restore in progress
+	// must never be observable as a half-updated struct. deliveries,
+	// callbackSecrets, and apiKeys aren't guarded: RestoreFrom doesn't
+	// reassign them (see its doc comment).
+	mu            sync.RWMutex
+	db            *sql.DB
+	jobs          *jobrepo.CachedRepo
+	notifications *notificationrepo.Repo
+	users         *userrepo.Repo
+	templates     *templaterepo.Repo
+
+	deliveries      *deliveryrepo.Repo
+	callbackSecrets *callbackrepo.Repo
+	apiKeys         *apikeyrepo.Repo
+}
+
+// conn returns the current underlying *sql.DB under a read lock, so a
+// RestoreFrom swapping it for a freshly reopened one mid-request can't be
+// observed half-done.
+func (s *SQLiteStore) conn() *sql.DB {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db
 }
 
 // NewSQLiteStore creates a new SQLite store
@@ -25,15 +64,42 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	store := &SQLiteStore{db: db}
+	store := &SQLiteStore{
+		db:     db,
+		dbPath: dbPath,
+		notify: make(chan struct{}, 1),
+	}
 
 	if err := store.init(); err != nil {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	store.jobs = jobrepo.NewCached(jobrepo.New(db), 1024)
+	store.notifications = notificationrepo.New(db)
+	store.users = userrepo.New(db)
+	store.templates = templaterepo.New(db)
+	store.deliveries = deliveryrepo.New(db)
+	store.callbackSecrets = callbackrepo.New(db)
+	store.apiKeys = apikeyrepo.New(db)
+
 	return store, nil
 }
 
+// Notify returns the channel that receives a signal every time a job is
+// saved. The channel is buffered to size 1, so callers should drain it in a
+// loop rather than assuming one signal per job.
+func (s *SQLiteStore) Notify() <-chan struct{} {
+	return s.notify
+}
+
+// wake signals Notify without blocking if nobody is listening yet.
+func (s *SQLiteStore) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
 // init creates required tables
 func (s *SQLiteStore) init() error {
 	schema := `
@@ -77,23 +143,153 @@ func (s *SQLiteStore) init() error {
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		notification_id INTEGER NOT NULL,
+		attempt INTEGER NOT NULL,
+		status_code INTEGER,
+		error TEXT,
+		attempted_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_retries (
+		notification_id INTEGER PRIMARY KEY,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at DATETIME NOT NULL,
+		last_error TEXT
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		notification_id INTEGER NOT NULL,
+		channel TEXT NOT NULL,
+		recipient TEXT NOT NULL,
+		subject TEXT,
+		message TEXT,
+		attempts INTEGER NOT NULL,
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		replayed_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS callback_secrets (
+		callback_id TEXT PRIMARY KEY,
+		secret TEXT NOT NULL,
+		rotated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id TEXT PRIMARY KEY,
+		hash TEXT NOT NULL,
+		roles TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		revoked_at DATETIME
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications(status);
 	CREATE INDEX IF NOT EXISTS idx_notifications_channel ON notifications(channel);
 	CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.conn().Exec(schema); err != nil {
+		return err
+	}
+
+	// acquired_by tracks which worker currently owns an in-flight job, so
+	// the Acquirer never hands the same job to two workers. Added via
+	// ALTER TABLE for existing databases created before this column
+	// existed; SQLite has no "ADD COLUMN IF NOT EXISTS", so a duplicate
+	// column error here just means the migration already ran.
+	if _, err := s.conn().Exec(`ALTER TABLE jobs ADD COLUMN acquired_by TEXT`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column") {
+			return err
+		}
+	}
+
+	// priority lets LoadPendingJobs/AcquireJob dispatch higher-priority
+	// jobs first without decoding the data blob for every row.
+	if _, err := s.conn().Exec(`ALTER TABLE jobs ADD COLUMN priority INTEGER DEFAULT 0`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column") {
+			return err
+		}
+	}
+	if _, err := s.conn().Exec(`CREATE INDEX IF NOT EXISTS idx_jobs_priority ON jobs(priority DESC, created_at ASC)`); err != nil {
+		return err
+	}
+
+	// locked_until is the visibility-timeout deadline for a job leased out
+	// to a remote worker over queue/rpc. It is left NULL for jobs claimed
+	// in-process by AcquireJob, which never needs a lease: the owning
+	// goroutine either finishes the job or the process dies with it.
+	if _, err := s.conn().Exec(`ALTER TABLE jobs ADD COLUMN locked_until DATETIME`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column") {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Close closes the database connection
 func (s *SQLiteStore) Close() error {
-	return s.db.Close()
+	return s.conn().Close()
+}
+
+// Jobs returns a typed, LRU-cached repository over the jobs table. New code
+// should prefer this over the ad-hoc Save/Load/UpdateJobStatus methods
+// below, which remain for the callers not yet migrated to it.
+func (s *SQLiteStore) Jobs() *jobrepo.CachedRepo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.jobs
+}
+
+// Notifications returns a typed repository over the notifications table
+// with an injection-safe filter builder, replacing the raw-string
+// orderBy/limit parameters on ListNotifications below.
+func (s *SQLiteStore) Notifications() *notificationrepo.Repo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.notifications
+}
+
+// Deliveries returns a typed repository over the webhook delivery attempt
+// log, retry backoff state, and dead-letter table.
+func (s *SQLiteStore) Deliveries() *deliveryrepo.Repo {
+	return s.deliveries
+}
+
+// Users returns a typed repository over the users table.
+func (s *SQLiteStore) Users() *userrepo.Repo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.users
+}
+
+// Templates returns a typed repository over the templates table.
+func (s *SQLiteStore) Templates() *templaterepo.Repo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.templates
+}
+
+// CallbackSecrets returns a typed repository over each callback ID's
+// current signing secret, replacing the old static Config.Callbacks map as
+// the source of truth ProcessCallback and RotateCallbackSecret read/write.
+func (s *SQLiteStore) CallbackSecrets() *callbackrepo.Repo {
+	return s.callbackSecrets
+}
+
+// APIKeys returns a typed repository over every currently-active hashed
+// API key, the set auth.APIKeyAuthenticator accepts a presented key's
+// hash against.
+func (s *SQLiteStore) APIKeys() *apikeyrepo.Repo {
+	return s.apiKeys
 }
 
 // Ping checks database connectivity
 func (s *SQLiteStore) Ping() string {
-	if err := s.db.Ping(); err != nil {
+	if err := s.conn().Ping(); err != nil {
 		return "unhealthy: " + err.Error()
 	}
 	return "healthy"
@@ -101,7 +297,7 @@ func (s *SQLiteStore) Ping() string {
 
 // SaveNotification stores a notification
 func (s *SQLiteStore) SaveNotification(n *channels.Notification) (int64, error) {
-	result, err := s.db.Exec(`
+	result, err := s.conn().Exec(`
 		INSERT INTO notifications (channel, recipient, subject, message, status, metadata)
 		VALUES (?, ?, ?, ?, 'pending', ?)
 	`, n.Channel, n.Recipient, n.Subject, n.Message, metadataToJSON(n.Metadata))
@@ -117,12 +313,12 @@ func (s *SQLiteStore) UpdateStatus(id int64, status, errorMsg string) error {
 	var query string
 	if status == "sent" {
 		query = `UPDATE notifications SET status = ?, sent_at = CURRENT_TIMESTAMP WHERE id = ?`
-		_, err := s.db.Exec(query, status, id)
+		_, err := s.conn().Exec(query, status, id)
 		return err
 	}
 
 	query = `UPDATE notifications SET status = ?, error = ? WHERE id = ?`
-	_, err := s.db.Exec(query, status, errorMsg, id)
+	_, err := s.conn().Exec(query, status, errorMsg, id)
 	return err
 }
 
@@ -164,7 +360,7 @@ func (s *SQLiteStore) ListNotifications(channel, status, recipient, limit, order
 
 	log.Printf("Executing query: %s", query) // VULN: Logs potentially malicious query
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.conn().Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +382,7 @@ func (s *SQLiteStore) Search(searchQuery string) ([]map[string]interface{}, erro
 		LIMIT 100
 	`, searchQuery, searchQuery) // TAINT SINK: SQL Injection
 
-	rows, err := s.db.Query(query)
+	rows, err := s.conn().Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -199,7 +395,7 @@ func (s *SQLiteStore) Search(searchQuery string) ([]map[string]interface{}, erro
 func (s *SQLiteStore) GetNotificationByID(id string) (map[string]interface{}, error) {
 	// VULN: SQL Injection via ID
 	query := fmt.Sprintf("SELECT * FROM notifications WHERE id = %s", id) // TAINT SINK
-	row := s.db.QueryRow(query)
+	row := s.conn().QueryRow(query)
 
 	var n channels.Notification
 	err := row.Scan(&n.ID, &n.Channel, &n.Recipient, &n.Subject, &n.Message, &n.Status)
@@ -210,21 +406,190 @@ func (s *SQLiteStore) GetNotificationByID(id string) (map[string]interface{}, er
 	return map[string]interface{}{"notification": n}, nil
 }
 
-// SaveJob stores a job
+// SaveJob stores a job at the default priority. Prefer SaveJobWithPriority
+// for jobs that should jump the queue.
 func (s *SQLiteStore) SaveJob(id, data string) error {
-	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO jobs (id, data, status, updated_at)
-		VALUES (?, ?, 'pending', CURRENT_TIMESTAMP)
-	`, id, data)
-	return err
+	return s.SaveJobWithPriority(id, data, 0)
+}
+
+// SaveJobWithPriority stores a job, recording priority in its own column so
+// LoadPendingJobs/AcquireJob can order by it without decoding the data
+// blob for every row.
+func (s *SQLiteStore) SaveJobWithPriority(id, data string, priority int) error {
+	_, err := s.conn().Exec(`
+		INSERT OR REPLACE INTO jobs (id, data, status, priority, updated_at)
+		VALUES (?, ?, 'pending', ?, CURRENT_TIMESTAMP)
+	`, id, data, priority)
+	if err != nil {
+		return err
+	}
+
+	s.wake()
+	return nil
+}
+
+// requiredTagKeyRe restricts tag keys allowed into the required_tags
+// json_extract path below. Tags are sourced from callers (including, via
+// queue/rpc, remote agents authenticated only by a shared secret) and
+// appendTagFilter interpolates the key into the query text itself since
+// SQLite doesn't support binding a JSON path segment as a parameter - so an
+// unvalidated key would let an agent break out of the json_extract path
+// literal and rewrite the WHERE clause.
+var requiredTagKeyRe = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// appendTagFilter extends query with an AND clause per tag requiring the
+// job's data blob to either omit that required_tags key or match its value,
+// validating each key against requiredTagKeyRe first since it's interpolated
+// into the query text rather than bound as a parameter.
+func appendTagFilter(query string, tags map[string]string, args []interface{}) (string, []interface{}, error) {
+	for key, value := range tags {
+		if !requiredTagKeyRe.MatchString(key) {
+			return "", nil, fmt.Errorf("invalid tag key %q", key)
+		}
+		query += fmt.Sprintf(" AND (json_extract(data, '$.required_tags.%s') IS NULL OR json_extract(data, '$.required_tags.%s') = ?)", key, key)
+		args = append(args, value)
+	}
+	return query, args, nil
+}
+
+// AcquireJob atomically claims the next eligible job for workerID and
+// returns its raw data blob, so the same job is never handed to two
+// workers. A job is eligible when it is pending/resuming, not scheduled for
+// the future, and, for each tag supplied, its data blob's "required_tags"
+// object either omits that key or has the same value; required_tags keys
+// that tags does not name are not checked. Jobs are served highest
+// priority first, then oldest first. ok is false with a nil error when no eligible job exists.
+func (s *SQLiteStore) AcquireJob(workerID string, tags map[string]string) (id string, data string, ok bool, err error) {
+	tx, err := s.conn().Begin()
+	if err != nil {
+		return "", "", false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, data FROM jobs
+		WHERE status IN ('pending', 'resuming')
+		  AND (json_extract(data, '$.scheduled_at') IS NULL
+		       OR json_extract(data, '$.scheduled_at') <= datetime('now'))
+	`
+	query, args, err := appendTagFilter(query, tags, nil)
+	if err != nil {
+		return "", "", false, err
+	}
+	query += " ORDER BY priority DESC, created_at ASC LIMIT 1"
+
+	row := tx.QueryRow(query, args...)
+	if err := row.Scan(&id, &data); err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", false, nil
+		}
+		return "", "", false, err
+	}
+
+	if _, err := tx.Exec(`UPDATE jobs SET status = 'acquired', acquired_by = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, workerID, id); err != nil {
+		return "", "", false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", false, err
+	}
+
+	return id, data, true, nil
+}
+
+// LeaseJob atomically claims the next eligible job for a remote worker
+// connected over queue/rpc, the same way AcquireJob does for in-process
+// workers, but additionally stamps locked_until with a visibility-timeout
+// deadline. The lease must be renewed via ExtendLease before it expires or
+// ReapExpiredLeases will requeue the job for another worker to pick up.
+func (s *SQLiteStore) LeaseJob(workerID string, tags map[string]string, leaseTTL time.Duration) (id string, data string, ok bool, err error) {
+	tx, err := s.conn().Begin()
+	if err != nil {
+		return "", "", false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, data FROM jobs
+		WHERE status IN ('pending', 'resuming')
+		  AND (json_extract(data, '$.scheduled_at') IS NULL
+		       OR json_extract(data, '$.scheduled_at') <= datetime('now'))
+	`
+	query, args, err := appendTagFilter(query, tags, nil)
+	if err != nil {
+		return "", "", false, err
+	}
+	query += " ORDER BY priority DESC, created_at ASC LIMIT 1"
+
+	row := tx.QueryRow(query, args...)
+	if err := row.Scan(&id, &data); err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", false, nil
+		}
+		return "", "", false, err
+	}
+
+	leaseSeconds := int(leaseTTL.Seconds())
+	if _, err := tx.Exec(`
+		UPDATE jobs
+		SET status = 'acquired', acquired_by = ?, locked_until = datetime('now', ? || ' seconds'), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, workerID, leaseSeconds, id); err != nil {
+		return "", "", false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", "", false, err
+	}
+
+	return id, data, true, nil
+}
+
+// ExtendLease pushes a leased job's locked_until deadline out by ttl,
+// provided workerID still matches the worker that holds the lease. It
+// reports whether the lease was found and still owned by workerID.
+func (s *SQLiteStore) ExtendLease(jobID, workerID string, ttl time.Duration) (bool, error) {
+	result, err := s.conn().Exec(`
+		UPDATE jobs
+		SET locked_until = datetime('now', ? || ' seconds'), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND acquired_by = ?
+	`, int(ttl.Seconds()), jobID, workerID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
 }
 
-// LoadPendingJobs retrieves pending jobs
+// ReapExpiredLeases requeues every leased job whose locked_until deadline
+// has passed, so a remote worker that died or lost its connection doesn't
+// strand the job indefinitely. Jobs acquired by AcquireJob (locked_until
+// IS NULL) are never touched.
+func (s *SQLiteStore) ReapExpiredLeases() (int64, error) {
+	result, err := s.conn().Exec(`
+		UPDATE jobs
+		SET status = 'resuming', acquired_by = NULL, locked_until = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'acquired' AND locked_until IS NOT NULL AND locked_until < datetime('now')
+	`)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err == nil && n > 0 {
+		s.wake()
+	}
+	return n, err
+}
+
+// LoadPendingJobs retrieves jobs ready to run, including jobs that are
+// resuming from a paused state. Jobs in 'paused' or 'pause-requested'
+// are intentionally excluded so a paused job is never handed back to a
+// worker until it has been explicitly resumed.
 func (s *SQLiteStore) LoadPendingJobs() ([]string, error) {
-	rows, err := s.db.Query(`
+	rows, err := s.conn().Query(`
 		SELECT data FROM jobs
-		WHERE status = 'pending'
-		ORDER BY created_at ASC
+		WHERE status IN ('pending', 'resuming')
+		ORDER BY priority DESC, created_at ASC
 		LIMIT 100
 	`)
 	if err != nil {
@@ -246,17 +611,91 @@ func (s *SQLiteStore) LoadPendingJobs() ([]string, error) {
 
 // UpdateJobStatus updates job status
 func (s *SQLiteStore) UpdateJobStatus(jobID, status, errorMsg string) error {
-	_, err := s.db.Exec(`
+	_, err := s.conn().Exec(`
 		UPDATE jobs SET status = ?, error = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`, status, errorMsg, jobID)
 	return err
 }
 
+// UpdateJobData replaces the stored job payload (retry counters, scheduled
+// time, etc.) without touching status. Used when checkpointing a job before
+// it transitions to 'paused'.
+func (s *SQLiteStore) UpdateJobData(jobID, data string) error {
+	_, err := s.conn().Exec(`
+		UPDATE jobs SET data = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, data, jobID)
+	return err
+}
+
+// PauseJob marks a single job for pausing: one that's still queued
+// ('pending'/'resuming') is paused immediately, while one currently leased
+// by a worker ('acquired') is marked 'pause-requested' so the worker
+// checkpoints it at its next safe boundary (see
+// queue.Worker.checkpointIfPauseRequested). Reports false, nil if jobID
+// wasn't in an eligible state.
+func (s *SQLiteStore) PauseJob(jobID string) (bool, error) {
+	result, err := s.conn().Exec(`
+		UPDATE jobs
+		SET status = CASE WHEN status = 'acquired' THEN 'pause-requested' ELSE 'paused' END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN ('pending', 'resuming', 'acquired')
+	`, jobID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ResumeJob flips a single paused job back to 'resuming' so the next
+// LoadPendingJobs pass picks it up. Reports false, nil if jobID wasn't
+// 'paused'.
+func (s *SQLiteStore) ResumeJob(jobID string) (bool, error) {
+	result, err := s.conn().Exec(`
+		UPDATE jobs SET status = 'resuming', updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'paused'
+	`, jobID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// PauseJobsByType marks every pending job whose data blob carries the given
+// job type as 'paused', so operators can drain a misbehaving downstream
+// without losing queued work. It relies on SQLite's JSON1 extension to avoid
+// decoding every row in Go.
+func (s *SQLiteStore) PauseJobsByType(jobType string) (int64, error) {
+	result, err := s.conn().Exec(`
+		UPDATE jobs SET status = 'paused', updated_at = CURRENT_TIMESTAMP
+		WHERE status IN ('pending', 'resuming') AND json_extract(data, '$.type') = ?
+	`, jobType)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ResumeJobsByType flips every 'paused' job of the given type back to
+// 'resuming' so the next loadPendingJobs pass picks it up.
+func (s *SQLiteStore) ResumeJobsByType(jobType string) (int64, error) {
+	result, err := s.conn().Exec(`
+		UPDATE jobs SET status = 'resuming', updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'paused' AND json_extract(data, '$.type') = ?
+	`, jobType)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // GetJobStatus returns job status
 func (s *SQLiteStore) GetJobStatus(jobID string) (string, error) {
 	var status string
-	err := s.db.QueryRow("SELECT status FROM jobs WHERE id = ?", jobID).Scan(&status)
+	err := s.conn().QueryRow("SELECT status FROM jobs WHERE id = ?", jobID).Scan(&status)
 	return status, err
 }
 
@@ -264,7 +703,7 @@ func (s *SQLiteStore) GetJobStatus(jobID string) (string, error) {
 // VULN: Weak password handling
 func (s *SQLiteStore) CreateUser(username, email, password string) error {
 	// VULN: Password stored in plaintext
-	_, err := s.db.Exec(`
+	_, err := s.conn().Exec(`
 		INSERT INTO users (username, email, password, api_key)
 		VALUES (?, ?, ?, ?)
 	`, username, email, password, generateAPIKey()) // VULN: Plaintext password
@@ -278,7 +717,7 @@ func (s *SQLiteStore) AuthenticateUser(username, password string) (bool, error)
 	// VULN: SQL Injection via username
 	query := fmt.Sprintf("SELECT password FROM users WHERE username = '%s'", username) // TAINT SINK
 	var storedPassword string
-	err := s.db.QueryRow(query).Scan(&storedPassword)
+	err := s.conn().QueryRow(query).Scan(&storedPassword)
 	if err != nil {
 		return false, err
 	}
@@ -293,7 +732,7 @@ func (s *SQLiteStore) AuthenticateUser(username, password string) (bool, error)
 func (s *SQLiteStore) GetUserByAPIKey(apiKey string) (map[string]interface{}, error) {
 	// VULN: SQL Injection via API key
 	query := fmt.Sprintf("SELECT id, username, email, role FROM users WHERE api_key = '%s'", apiKey)
-	row := s.db.QueryRow(query) // TAINT SINK
+	row := s.conn().QueryRow(query) // TAINT SINK
 
 	var id int
 	var username, email, role string
@@ -312,7 +751,7 @@ func (s *SQLiteStore) GetUserByAPIKey(apiKey string) (map[string]interface{}, er
 // SaveTemplate stores a template
 // VULN: Template content stored without sanitization
 func (s *SQLiteStore) SaveTemplate(name, content, createdBy string) error {
-	_, err := s.db.Exec(`
+	_, err := s.conn().Exec(`
 		INSERT OR REPLACE INTO templates (name, content, created_by)
 		VALUES (?, ?, ?)
 	`, name, content, createdBy) // VULN: Malicious template content stored
@@ -324,7 +763,7 @@ func (s *SQLiteStore) GetTemplate(name string) (string, error) {
 	// VULN: SQL Injection via template name
 	query := fmt.Sprintf("SELECT content FROM templates WHERE name = '%s'", name) // TAINT SINK
 	var content string
-	err := s.db.QueryRow(query).Scan(&content)
+	err := s.conn().QueryRow(query).Scan(&content)
 	return content, err
 }
 
@@ -332,7 +771,7 @@ func (s *SQLiteStore) GetTemplate(name string) (string, error) {
 // VULN: SQL Injection
 func (s *SQLiteStore) DeleteNotification(id string) error {
 	query := fmt.Sprintf("DELETE FROM notifications WHERE id = %s", id) // TAINT SINK
-	_, err := s.db.Exec(query)
+	_, err := s.conn().Exec(query)
 	return err
 }
 
@@ -342,7 +781,7 @@ func (s *SQLiteStore) BulkDelete(ids []string) error {
 	// VULN: IDs joined directly into query
 	idList := strings.Join(ids, ",")
 	query := fmt.Sprintf("DELETE FROM notifications WHERE id IN (%s)", idList) // TAINT SINK
-	_, err := s.db.Exec(query)
+	_, err := s.conn().Exec(query)
 	return err
 }
 
@@ -350,7 +789,7 @@ func (s *SQLiteStore) BulkDelete(ids []string) error {
 // VULN: Direct SQL execution
 func (s *SQLiteStore) ExecRawQuery(query string) ([]map[string]interface{}, error) {
 	// VULN: Arbitrary SQL execution
-	rows, err := s.db.Query(query) // TAINT SINK: Direct SQL injection
+	rows, err := s.conn().Query(query) // TAINT SINK: Direct SQL injection
 	if err != nil {
 		return nil, err
 	}
@@ -359,23 +798,6 @@ func (s *SQLiteStore) ExecRawQuery(query string) ([]map[string]interface{}, erro
 	return scanToMaps(rows)
 }
 
-// Backup creates a database backup
-// VULN: Command injection via backup path
-func (s *SQLiteStore) Backup(backupPath string) error {
-	// VULN: Path in shell command
-	cmdStr := fmt.Sprintf("sqlite3 notifications.db '.backup %s'", backupPath)
-	cmd := exec.Command("sh", "-c", cmdStr) // TAINT SINK: Command injection
-	return cmd.Run()
-}
-
-// Restore restores from a backup
-// VULN: Command injection via backup path
-func (s *SQLiteStore) Restore(backupPath string) error {
-	cmdStr := fmt.Sprintf("sqlite3 notifications.db '.restore %s'", backupPath)
-	cmd := exec.Command("sh", "-c", cmdStr) // TAINT SINK
-	return cmd.Run()
-}
-
 // Helper functions
 func scanNotifications(rows *sql.Rows) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
@@ -0,0 +1,183 @@
+// Package deliveryrepo provides typed, parameterized access to the
+// webhook delivery tables: the attempt log, in-flight retry backoff
+// state, and the dead-letter table notifications land in once retries
+// are exhausted.
+package deliveryrepo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/project-anarchy/go_notifications/internal/channels"
+)
+
+// Retry is the current backoff state for a notification still being
+// retried.
+type Retry struct {
+	NotificationID int64
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+}
+
+// DeadLetter is a notification that exhausted its retry budget.
+type DeadLetter struct {
+	ID             int64
+	NotificationID int64
+	Channel        string
+	Recipient      string
+	Subject        string
+	Message        string
+	Attempts       int
+	LastError      string
+	CreatedAt      time.Time
+	ReplayedAt     *time.Time
+}
+
+// Repo provides typed access to the webhook delivery tables.
+type Repo struct {
+	db *sql.DB
+}
+
+// New creates a Repo backed by db.
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// RecordAttempt appends one row to the delivery attempt log.
+func (r *Repo) RecordAttempt(ctx context.Context, notificationID int64, attempt, statusCode int, attemptErr string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (notification_id, attempt, status_code, error)
+		VALUES (?, ?, ?, ?)
+	`, notificationID, attempt, statusCode, attemptErr)
+	return err
+}
+
+// ScheduleRetry upserts the backoff state for notificationID.
+func (r *Repo) ScheduleRetry(ctx context.Context, notificationID int64, attempts int, nextAttemptAt time.Time, lastErr string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO webhook_retries (notification_id, attempts, next_attempt_at, last_error)
+		VALUES (?, ?, ?, ?)
+	`, notificationID, attempts, nextAttemptAt, lastErr)
+	return err
+}
+
+// DueRetries returns every retry whose next_attempt_at has passed.
+func (r *Repo) DueRetries(ctx context.Context, now time.Time) ([]Retry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT notification_id, attempts, next_attempt_at, last_error
+		FROM webhook_retries WHERE next_attempt_at <= ?
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Retry
+	for rows.Next() {
+		var rt Retry
+		var lastErr sql.NullString
+		if err := rows.Scan(&rt.NotificationID, &rt.Attempts, &rt.NextAttemptAt, &lastErr); err != nil {
+			return nil, err
+		}
+		rt.LastError = lastErr.String
+		out = append(out, rt)
+	}
+	return out, rows.Err()
+}
+
+// ClearRetry removes notificationID's backoff state, once it has either
+// been delivered successfully or dead-lettered.
+func (r *Repo) ClearRetry(ctx context.Context, notificationID int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM webhook_retries WHERE notification_id = ?`, notificationID)
+	return err
+}
+
+// MoveToDeadLetter records n as dead-lettered and clears its retry state,
+// in one transaction.
+func (r *Repo) MoveToDeadLetter(ctx context.Context, n *channels.Notification, attempts int, lastErr string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO webhook_dead_letters (notification_id, channel, recipient, subject, message, attempts, last_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, n.ID, n.Channel, n.Recipient, n.Subject, n.Message, attempts, lastErr); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_retries WHERE notification_id = ?`, n.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListDeadLetters returns every dead-lettered notification, newest first.
+func (r *Repo) ListDeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, notification_id, channel, recipient, subject, message, attempts, last_error, created_at, replayed_at
+		FROM webhook_dead_letters ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, dl)
+	}
+	return out, rows.Err()
+}
+
+// GetDeadLetter returns a single dead-lettered notification by its
+// dead-letter ID.
+func (r *Repo) GetDeadLetter(ctx context.Context, id int64) (*DeadLetter, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, notification_id, channel, recipient, subject, message, attempts, last_error, created_at, replayed_at
+		FROM webhook_dead_letters WHERE id = ?
+	`, id)
+
+	dl, err := scanDeadLetter(row)
+	if err != nil {
+		return nil, err
+	}
+	return &dl, nil
+}
+
+// MarkReplayed timestamps a dead-lettered notification as successfully
+// replayed.
+func (r *Repo) MarkReplayed(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_dead_letters SET replayed_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, id)
+	return err
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeadLetter(row rowScanner) (DeadLetter, error) {
+	var dl DeadLetter
+	var lastErr sql.NullString
+	var replayedAt sql.NullTime
+	err := row.Scan(&dl.ID, &dl.NotificationID, &dl.Channel, &dl.Recipient, &dl.Subject, &dl.Message,
+		&dl.Attempts, &lastErr, &dl.CreatedAt, &replayedAt)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	dl.LastError = lastErr.String
+	if replayedAt.Valid {
+		dl.ReplayedAt = &replayedAt.Time
+	}
+	return dl, nil
+}
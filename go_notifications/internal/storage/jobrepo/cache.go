@@ -0,0 +1,114 @@
+package jobrepo
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// CachedRepo decorates a Repo with an LRU cache for GetStatus, the hottest
+// read path (polled repeatedly by API clients tracking a job). Writes
+// invalidate the cached entry so callers never observe a stale status.
+type CachedRepo struct {
+	*Repo
+
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type cacheEntry struct {
+	id     string
+	status Status
+}
+
+// NewCached wraps repo with an LRU cache holding up to capacity entries.
+func NewCached(repo *Repo, capacity int) *CachedRepo {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &CachedRepo{
+		Repo:     repo,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// GetStatus returns the cached status for id if present, otherwise falls
+// through to the underlying Repo and caches the result.
+func (c *CachedRepo) GetStatus(ctx context.Context, id string) (Status, error) {
+	if status, ok := c.lookup(id); ok {
+		return status, nil
+	}
+
+	status, err := c.Repo.GetStatus(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	c.store(id, status)
+	return status, nil
+}
+
+// UpdateStatus writes through to the underlying Repo and refreshes the
+// cached entry so readers never see a stale value.
+func (c *CachedRepo) UpdateStatus(ctx context.Context, id string, status Status, errMsg string) error {
+	if err := c.Repo.UpdateStatus(ctx, id, status, errMsg); err != nil {
+		return err
+	}
+	c.store(id, status)
+	return nil
+}
+
+// Invalidate evicts id's cached entry, if any, so the next GetStatus call
+// re-reads its current status from the underlying Repo. Callers that move a
+// job's status via a write that doesn't go through UpdateStatus (e.g. a
+// guarded conditional UPDATE that only applies from certain prior statuses)
+// must call this afterward, or a cached reader keeps observing the
+// pre-write value.
+func (c *CachedRepo) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[id]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, id)
+	}
+}
+
+func (c *CachedRepo) lookup(id string) (Status, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).status, true
+}
+
+func (c *CachedRepo) store(id string, status Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[id]; ok {
+		elem.Value.(*cacheEntry).status = status
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	elem := c.order.PushFront(&cacheEntry{id: id, status: status})
+	c.entries[id] = elem
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).id)
+	}
+}
@@ -0,0 +1,153 @@
+// Package jobrepo provides a typed persistence layer for queued jobs,
+// separate from the rest of storage.SQLiteStore's notification/user/
+// template concerns.
+package jobrepo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Status is a job's lifecycle state, mirroring the status strings the
+// queue package assigns (pending, acquired, paused, completed, ...).
+type Status string
+
+// Job is the typed row shape for the jobs table. Data holds the
+// JSON-encoded queue.Job payload; jobrepo treats it as opaque so it has no
+// dependency on the queue package.
+type Job struct {
+	ID         string
+	Data       string
+	Status     Status
+	Error      string
+	AcquiredBy string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Filter narrows ListPending to a subset of jobs.
+type Filter struct {
+	Statuses []Status
+	Limit    int
+}
+
+// Repo provides strongly-typed access to the jobs table.
+type Repo struct {
+	db dbtx
+}
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx, letting WithTx share the
+// exact same query implementations as the top-level Repo.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// New creates a Repo backed by db.
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Insert stores a new job in the 'pending' state.
+func (r *Repo) Insert(ctx context.Context, id, data string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO jobs (id, data, status, updated_at)
+		VALUES (?, ?, 'pending', CURRENT_TIMESTAMP)
+	`, id, data)
+	return err
+}
+
+// ListPending returns jobs matching filter, oldest first.
+func (r *Repo) ListPending(ctx context.Context, filter Filter) ([]*Job, error) {
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []Status{"pending"}
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, 0, len(statuses)+1)
+	for i, s := range statuses {
+		placeholders[i] = "?"
+		args = append(args, string(s))
+	}
+	args = append(args, limit)
+
+	query := `SELECT id, data, status, error, acquired_by, created_at, updated_at FROM jobs WHERE status IN (` +
+		joinPlaceholders(placeholders) + `) ORDER BY created_at ASC LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		var j Job
+		var acquiredBy sql.NullString
+		var errMsg sql.NullString
+		if err := rows.Scan(&j.ID, &j.Data, &j.Status, &errMsg, &acquiredBy, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, err
+		}
+		j.Error = errMsg.String
+		j.AcquiredBy = acquiredBy.String
+		jobs = append(jobs, &j)
+	}
+	return jobs, rows.Err()
+}
+
+// UpdateStatus updates a job's status and error message.
+func (r *Repo) UpdateStatus(ctx context.Context, id string, status Status, errMsg string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, error = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, string(status), errMsg, id)
+	return err
+}
+
+// GetStatus returns a single job's current status.
+func (r *Repo) GetStatus(ctx context.Context, id string) (Status, error) {
+	var status Status
+	err := r.db.QueryRowContext(ctx, `SELECT status FROM jobs WHERE id = ?`, id).Scan(&status)
+	return status, err
+}
+
+// WithTx runs fn against a Repo bound to a single transaction, committing on
+// success and rolling back on error or panic.
+func (r *Repo) WithTx(ctx context.Context, db *sql.DB, fn func(*Repo) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	txRepo := &Repo{db: tx}
+
+	if err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				tx.Rollback()
+				panic(p)
+			}
+		}()
+		return fn(txRepo)
+	}(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func joinPlaceholders(placeholders []string) string {
+	out := placeholders[0]
+	for _, p := range placeholders[1:] {
+		out += ", " + p
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+// Package notificationrepo provides typed, injection-safe access to the
+// notifications table, replacing the map[string]interface{} results and
+// string-concatenated ORDER BY/LIMIT clauses in storage.SQLiteStore.
+package notificationrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/project-anarchy/go_notifications/internal/channels"
+)
+
+// OrderColumn whitelists the columns callers may sort by. Only these
+// identifiers can ever reach the generated SQL, so a Filter can never be
+// used to smuggle arbitrary SQL into the ORDER BY clause.
+type OrderColumn string
+
+const (
+	OrderByCreatedAt OrderColumn = "created_at"
+	OrderByID        OrderColumn = "id"
+	OrderByStatus    OrderColumn = "status"
+	OrderByChannel   OrderColumn = "channel"
+)
+
+// Direction whitelists sort direction.
+type Direction string
+
+const (
+	Asc  Direction = "ASC"
+	Desc Direction = "DESC"
+)
+
+// Filter narrows and orders a notification listing. Zero values fall back
+// to sensible defaults (created_at DESC, limit 100).
+type Filter struct {
+	Channel   string
+	Status    string
+	Recipient string
+	OrderBy   OrderColumn
+	Direction Direction
+	Limit     int
+}
+
+// Repo provides typed access to the notifications table.
+type Repo struct {
+	db *sql.DB
+}
+
+// New creates a Repo backed by db.
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// List returns notifications matching filter. Unlike
+// storage.SQLiteStore.ListNotifications, OrderBy and Direction are enums
+// rather than raw strings, so there is no SQL injection surface.
+func (r *Repo) List(ctx context.Context, filter Filter) ([]*channels.Notification, error) {
+	orderBy := filter.OrderBy
+	if orderBy == "" {
+		orderBy = OrderByCreatedAt
+	}
+	if !validOrderColumn(orderBy) {
+		return nil, fmt.Errorf("invalid order column: %s", orderBy)
+	}
+
+	direction := filter.Direction
+	if direction == "" {
+		direction = Desc
+	}
+	if direction != Asc && direction != Desc {
+		return nil, fmt.Errorf("invalid sort direction: %s", direction)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	query := `SELECT id, channel, recipient, subject, message, status, error, created_at, sent_at
+	          FROM notifications WHERE 1=1`
+	args := []interface{}{}
+
+	if filter.Channel != "" {
+		query += " AND channel = ?"
+		args = append(args, filter.Channel)
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Recipient != "" {
+		query += " AND recipient = ?"
+		args = append(args, filter.Recipient)
+	}
+
+	// orderBy/direction are validated against the enums above, so this
+	// concatenation can never introduce attacker-controlled SQL.
+	query += fmt.Sprintf(" ORDER BY %s %s LIMIT ?", orderBy, direction)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*channels.Notification
+	for rows.Next() {
+		var n channels.Notification
+		var errMsg sql.NullString
+		var sentAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Channel, &n.Recipient, &n.Subject, &n.Message, &n.Status, &errMsg, &n.CreatedAt, &sentAt); err != nil {
+			return nil, err
+		}
+		n.Error = errMsg.String
+		if sentAt.Valid {
+			n.SentAt = &sentAt.Time
+		}
+		results = append(results, &n)
+	}
+	return results, rows.Err()
+}
+
+// Get returns a single notification by ID.
+func (r *Repo) Get(ctx context.Context, id int64) (*channels.Notification, error) {
+	var n channels.Notification
+	var errMsg sql.NullString
+	var sentAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, channel, recipient, subject, message, status, error, created_at, sent_at
+		FROM notifications WHERE id = ?
+	`, id).Scan(&n.ID, &n.Channel, &n.Recipient, &n.Subject, &n.Message, &n.Status, &errMsg, &n.CreatedAt, &sentAt)
+	if err != nil {
+		return nil, err
+	}
+	n.Error = errMsg.String
+	if sentAt.Valid {
+		n.SentAt = &sentAt.Time
+	}
+	return &n, nil
+}
+
+func validOrderColumn(c OrderColumn) bool {
+	switch c {
+	case OrderByCreatedAt, OrderByID, OrderByStatus, OrderByChannel:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,68 @@
+// Package templaterepo provides typed, parameterized access to the
+// templates table.
+package templaterepo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Template is the typed row shape for the templates table.
+type Template struct {
+	ID        int64
+	Name      string
+	Content   string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// Repo provides typed access to the templates table.
+type Repo struct {
+	db *sql.DB
+}
+
+// New creates a Repo backed by db.
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Save stores or replaces a template by name.
+func (r *Repo) Save(ctx context.Context, name, content, createdBy string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO templates (name, content, created_by)
+		VALUES (?, ?, ?)
+	`, name, content, createdBy)
+	return err
+}
+
+// Get returns a template by name.
+func (r *Repo) Get(ctx context.Context, name string) (*Template, error) {
+	var t Template
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, name, content, created_by, created_at FROM templates WHERE name = ?
+	`, name).Scan(&t.ID, &t.Name, &t.Content, &t.CreatedBy, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// List returns the name of every stored template, alphabetically.
+func (r *Repo) List(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT name FROM templates ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
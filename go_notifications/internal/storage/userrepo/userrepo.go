@@ -0,0 +1,67 @@
+// Package userrepo provides typed, parameterized access to the users table.
+package userrepo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// User is the typed row shape for the users table.
+type User struct {
+	ID        int64
+	Username  string
+	Email     string
+	Role      string
+	APIKey    string
+	CreatedAt time.Time
+}
+
+// Repo provides typed access to the users table.
+type Repo struct {
+	db *sql.DB
+}
+
+// New creates a Repo backed by db.
+func New(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// Create inserts a new user. Callers are responsible for hashing password
+// and generating apiKey before calling Create; Repo does not hash or
+// generate credentials itself.
+func (r *Repo) Create(ctx context.Context, username, email, passwordHash, apiKey string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO users (username, email, password, api_key)
+		VALUES (?, ?, ?, ?)
+	`, username, email, passwordHash, apiKey)
+	return err
+}
+
+// GetByUsername returns a user's stored password hash alongside its typed
+// row, so callers can verify credentials without a second query.
+func (r *Repo) GetByUsername(ctx context.Context, username string) (*User, string, error) {
+	var u User
+	var passwordHash string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, username, email, password, role, api_key, created_at
+		FROM users WHERE username = ?
+	`, username).Scan(&u.ID, &u.Username, &u.Email, &passwordHash, &u.Role, &u.APIKey, &u.CreatedAt)
+	if err != nil {
+		return nil, "", err
+	}
+	return &u, passwordHash, nil
+}
+
+// GetByAPIKey returns the user owning apiKey.
+func (r *Repo) GetByAPIKey(ctx context.Context, apiKey string) (*User, error) {
+	var u User
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, username, email, role, api_key, created_at
+		FROM users WHERE api_key = ?
+	`, apiKey).Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.APIKey, &u.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
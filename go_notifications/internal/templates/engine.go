@@ -0,0 +1,190 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	defaultRenderTimeout  = 2 * time.Second
+	defaultMaxOutputBytes = 64 * 1024
+)
+
+// Rendered is the output of rendering a template for one channel.
+type Rendered struct {
+	Subject string
+	Body    string
+}
+
+// Engine renders named templates loaded from a TemplateStore. Unlike
+// Renderer, it only exposes a small set of pure string helpers (no exec,
+// file, or network access), and bounds both render time and output size
+// so a stored template can't hang a worker or exfiltrate data.
+type Engine struct {
+	store          TemplateStore
+	renderTimeout  time.Duration
+	maxOutputBytes int64
+}
+
+// NewEngine creates an Engine backed by store using the default timeout
+// and output cap.
+func NewEngine(store TemplateStore) *Engine {
+	return &Engine{
+		store:          store,
+		renderTimeout:  defaultRenderTimeout,
+		maxOutputBytes: defaultMaxOutputBytes,
+	}
+}
+
+// Render renders the named template for locale and channel. variant's base
+// Subject/Message is layered with channel's own override block, if the
+// template defines one (see LocaleVariant.channelOverride), and the result
+// is escaped the way that channel requires: HTML-escaped for email, so the
+// body is safe to send as an HTML part; JSON-escaped for Slack, so it's
+// safe to drop straight into a Block Kit/attachment JSON string without the
+// caller re-escaping it; plain text everywhere else (a webhook caller JSON-
+// encodes its whole payload itself, which escapes this for that context).
+func (e *Engine) Render(ctx context.Context, name, locale, channel string, data map[string]interface{}) (Rendered, error) {
+	def, err := e.store.Get(ctx, name)
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	variant, err := def.Variant(locale)
+	if err != nil {
+		return Rendered{}, fmt.Errorf("template %q: %w", name, err)
+	}
+
+	subjectSrc, messageSrc := variant.Subject, variant.Message
+	if override := variant.channelOverride(channel); override != nil {
+		if override.Subject != "" {
+			subjectSrc = override.Subject
+		}
+		if override.Message != "" {
+			messageSrc = override.Message
+		}
+	}
+
+	subject, err := e.renderText(subjectSrc, data)
+	if err != nil {
+		return Rendered{}, fmt.Errorf("render subject: %w", err)
+	}
+
+	var body string
+	switch channel {
+	case "email":
+		body, err = e.renderHTML(messageSrc, data)
+		if err != nil {
+			return Rendered{}, fmt.Errorf("render email body: %w", err)
+		}
+	case "slack":
+		body, err = e.renderSlack(messageSrc, data)
+		if err != nil {
+			return Rendered{}, fmt.Errorf("render slack body: %w", err)
+		}
+	default:
+		body, err = e.renderText(messageSrc, data)
+		if err != nil {
+			return Rendered{}, fmt.Errorf("render body: %w", err)
+		}
+	}
+
+	return Rendered{Subject: subject, Body: body}, nil
+}
+
+// sandboxedFuncs are the only functions a stored template may call -
+// pure string helpers with no access to the filesystem, environment, or
+// network, unlike the exec/env/readFile funcs Renderer's funcMapFor adds
+// for a spec's Caps.
+func sandboxedFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"replace":  strings.Replace,
+		"contains": strings.Contains,
+		"title":    strings.Title,
+	}
+}
+
+func (e *Engine) renderText(src string, data map[string]interface{}) (string, error) {
+	tmpl, err := texttemplate.New("t").Funcs(sandboxedFuncs()).Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return e.execute(func(w io.Writer) error { return tmpl.Execute(w, data) })
+}
+
+func (e *Engine) renderHTML(src string, data map[string]interface{}) (string, error) {
+	tmpl, err := htmltemplate.New("t").Funcs(sandboxedFuncs()).Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return e.execute(func(w io.Writer) error { return tmpl.Execute(w, data) })
+}
+
+// renderSlack renders src as plain text, then JSON-escapes it: the
+// result is the content of a JSON string literal (no surrounding quotes),
+// safe to embed directly into a SlackMessage's Text/Attachments or a
+// caller-supplied Block Kit document without that caller re-escaping it.
+func (e *Engine) renderSlack(src string, data map[string]interface{}) (string, error) {
+	rendered, err := e.renderText(src, data)
+	if err != nil {
+		return "", err
+	}
+	escaped, err := json.Marshal(rendered)
+	if err != nil {
+		return "", err
+	}
+	s := string(escaped)
+	return s[1 : len(s)-1], nil
+}
+
+// execute runs render against a size-capped buffer on its own goroutine
+// so a runaway template (e.g. an expensive range over attacker data)
+// can't block the caller past renderTimeout.
+func (e *Engine) execute(render func(io.Writer) error) (string, error) {
+	out := &cappedWriter{limit: e.maxOutputBytes}
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("template panicked: %v", r)
+			}
+		}()
+		done <- render(out)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", err
+		}
+		return out.buf.String(), nil
+	case <-time.After(e.renderTimeout):
+		return "", errors.New("template render timed out")
+	}
+}
+
+// cappedWriter errors out once more than limit bytes have been written,
+// rather than letting a template produce unbounded output.
+type cappedWriter struct {
+	buf   bytes.Buffer
+	limit int64
+}
+
+func (c *cappedWriter) Write(p []byte) (int, error) {
+	if int64(c.buf.Len())+int64(len(p)) > c.limit {
+		return 0, fmt.Errorf("template output exceeds %d byte limit", c.limit)
+	}
+	return c.buf.Write(p)
+}
@@ -3,256 +3,361 @@ package templates
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	htmltemplate "html/template"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
-	texttemplate "text/template"
+	"sync"
+	"time"
 )
 
-// Renderer handles template loading and rendering
+// Capability is a bitset of abilities a compiled template may use beyond
+// the default set of pure string/date/JSON funcs. A spec only gets the
+// funcs its Caps grant - no global exec/shell/readFile/writeFile/fetch
+// funcs are ever registered on every template the way Renderer used to.
+type Capability uint8
+
+const (
+	CapNone Capability = 0
+	CapFS   Capability = 1 << 0
+	CapExec Capability = 1 << 1
+	CapNet  Capability = 1 << 2
+	CapEnv  Capability = 1 << 3
+)
+
+// Has reports whether c grants every bit set in flag.
+func (c Capability) Has(flag Capability) bool {
+	return c&flag == flag
+}
+
+// maxIncludeDepth bounds recursive {{include}} expansion, so a template
+// that includes itself (or a cycle of templates) can't exhaust memory.
+const maxIncludeDepth = 8
+
+var (
+	includeRe  = regexp.MustCompile(`\{\{-?\s*include\s+"([^"]*)"\s*-?\}\}`)
+	defineRe   = regexp.MustCompile(`\{\{-?\s*define\s+"([^"]*)"\s*-?\}\}`)
+	templateRe = regexp.MustCompile(`\{\{-?\s*template\s+"([^"]*)"`)
+)
+
+// TemplateSpec is everything CompileSpec needs to compile one template:
+// its source, the capabilities it's granted, and (if it uses {{include}}
+// or CapFS's readFile) the filesystem root those may read under.
+type TemplateSpec struct {
+	Name   string
+	Source string
+	Caps   Capability
+	FSRoot string
+}
+
+// CompiledTemplate is a TemplateSpec parsed into an executable template.
+type CompiledTemplate struct {
+	spec TemplateSpec
+	tmpl *htmltemplate.Template
+}
+
+// Execute renders the compiled template against data.
+func (c *CompiledTemplate) Execute(data map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := c.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Renderer handles template loading and rendering. cache is guarded by mu
+// rather than left to callers - concurrent Render/RenderSpec calls for
+// different names previously raced on a bare map.
 type Renderer struct {
 	templatesDir string
-	cache        map[string]*htmltemplate.Template
+
+	mu    sync.RWMutex
+	cache map[string]*CompiledTemplate
 }
 
 // NewRenderer creates a new template renderer
 func NewRenderer(templatesDir string) *Renderer {
 	return &Renderer{
 		templatesDir: templatesDir,
-		cache:        make(map[string]*htmltemplate.Template),
+		cache:        make(map[string]*CompiledTemplate),
 	}
 }
 
-// Render renders a template with the provided data
-// VULN: Template path traversal and template injection
+// Render loads templateName from templatesDir, compiling it with CapNone
+// (no FS/exec/net/env access), and executes it against data. templateName
+// is resolved under templatesDir via resolveUnderRoot, so "../../etc/passwd"
+// style names are rejected rather than read.
 func (r *Renderer) Render(templateName string, data map[string]interface{}) (string, error) {
-	// VULN: Path traversal - templateName not sanitized
-	// templateName could be "../../../etc/passwd" or "../../secrets/config.yaml"
-	templatePath := filepath.Join(r.templatesDir, templateName) // TAINT SINK
-
-	// Try to load from cache first
-	tmpl, ok := r.cache[templateName]
-	if !ok {
-		// Load template from file
-		// VULN: Arbitrary file read via path traversal
-		content, err := os.ReadFile(templatePath) // TAINT SINK
-		if err != nil {
-			return "", fmt.Errorf("failed to load template %s: %w", templateName, err)
-		}
-
-		// Parse template
-		// VULN: User-controlled template content if file can be written elsewhere
-		tmpl, err = htmltemplate.New(templateName).Funcs(r.unsafeFuncMap()).Parse(string(content))
-		if err != nil {
-			return "", fmt.Errorf("failed to parse template: %w", err)
-		}
-
-		r.cache[templateName] = tmpl
+	compiled, err := r.compiled(templateName)
+	if err != nil {
+		return "", err
 	}
+	return compiled.Execute(data)
+}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil { // TAINT SINK: User data in template
-		return "", fmt.Errorf("failed to execute template: %w", err)
+// compiled returns templateName's compiled template, compiling it from
+// templatesDir and populating the cache on a miss. It is the shared path
+// behind Render and WatchingRenderer's PreloadAll, guarded by mu so
+// concurrent callers (and Reload, evicting the same entry) don't race.
+func (r *Renderer) compiled(templateName string) (*CompiledTemplate, error) {
+	r.mu.RLock()
+	compiled, ok := r.cache[templateName]
+	r.mu.RUnlock()
+	if ok {
+		return compiled, nil
 	}
 
-	return buf.String(), nil
-}
+	templatePath, err := resolveUnderRoot(r.templatesDir, templateName) // TAINT SINK: guarded
+	if err != nil {
+		return nil, fmt.Errorf("invalid template name %q: %w", templateName, err)
+	}
 
-// RenderString renders a template from a string
-// VULN: Server-Side Template Injection (SSTI)
-func (r *Renderer) RenderString(templateStr string, data map[string]interface{}) (string, error) {
-	// VULN: SSTI - User-controlled template string
-	// templateStr could contain: {{exec "id"}} or {{readFile "/etc/passwd"}}
-	tmpl, err := htmltemplate.New("inline").Funcs(r.unsafeFuncMap()).Parse(templateStr) // TAINT SINK
+	content, err := os.ReadFile(templatePath)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to load template %s: %w", templateName, err)
 	}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", err
+	compiled, err = r.CompileSpec(TemplateSpec{
+		Name:   templateName,
+		Source: string(content),
+		Caps:   CapNone,
+		FSRoot: r.templatesDir,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
 
-	return buf.String(), nil
+	r.mu.Lock()
+	r.cache[templateName] = compiled
+	r.mu.Unlock()
+	return compiled, nil
 }
 
-// RenderText renders using text/template (no HTML escaping)
-// VULN: XSS when output is used in HTML context
-func (r *Renderer) RenderText(templateStr string, data map[string]interface{}) (string, error) {
-	// VULN: text/template doesn't escape HTML - XSS risk
-	tmpl, err := texttemplate.New("text").Funcs(r.unsafeTextFuncMap()).Parse(templateStr)
+// Reload evicts name's compiled template from the cache, so the next
+// Render call recompiles it from disk (and the next RenderSpec call from
+// its spec's Source). Safe to call
+// concurrently with Render - used both by the admin reload endpoint and
+// by WatchingRenderer's fsnotify handler.
+func (r *Renderer) Reload(name string) {
+	r.mu.Lock()
+	delete(r.cache, name)
+	r.mu.Unlock()
+}
+
+// CompileSpec expands any {{include}} directives (restricted to
+// spec.FSRoot, bounded by maxIncludeDepth), rejects a {{define}}/{{template}}
+// that names anything but spec.Name (escaping the spec's own allowlist),
+// and parses the result with only the FuncMap spec.Caps permits.
+func (r *Renderer) CompileSpec(spec TemplateSpec) (*CompiledTemplate, error) {
+	expanded, err := expandIncludes(spec.Source, spec.FSRoot, 0)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", err
+	if err := rejectForeignDefines(expanded, spec.Name); err != nil {
+		return nil, err
 	}
 
-	return buf.String(), nil
-}
-
-// unsafeFuncMap returns template functions including dangerous ones
-// VULN: Dangerous template functions
-func (r *Renderer) unsafeFuncMap() htmltemplate.FuncMap {
-	return htmltemplate.FuncMap{
-		// VULN: Command execution from template
-		"exec": func(cmd string, args ...string) string {
-			output, _ := exec.Command(cmd, args...).Output() // TAINT SINK
-			return string(output)
-		},
+	tmpl, err := htmltemplate.New(spec.Name).Funcs(funcMapFor(spec)).Parse(expanded)
+	if err != nil {
+		return nil, err
+	}
 
-		// VULN: Arbitrary file read from template
-		"readFile": func(path string) string {
-			content, _ := os.ReadFile(path) // TAINT SINK
-			return string(content)
-		},
+	return &CompiledTemplate{spec: spec, tmpl: tmpl}, nil
+}
 
-		// VULN: Environment variable access
-		"env": func(key string) string {
-			return os.Getenv(key)
-		},
+// RenderSpec compiles (if not already cached under spec.Name) and executes
+// spec, but only if callerCaps covers everything spec.Caps grants - a
+// caller can't silently invoke a template compiled with more capability
+// than the token it presents.
+func (r *Renderer) RenderSpec(spec TemplateSpec, data map[string]interface{}, callerCaps Capability) (string, error) {
+	if spec.Caps&^callerCaps != 0 {
+		return "", fmt.Errorf("template %q requires capabilities the caller did not present", spec.Name)
+	}
 
-		// VULN: Shell command execution
-		"shell": func(cmd string) string {
-			output, _ := exec.Command("sh", "-c", cmd).Output() // TAINT SINK
-			return string(output)
-		},
+	r.mu.RLock()
+	compiled, ok := r.cache[spec.Name]
+	r.mu.RUnlock()
+	if !ok {
+		var err error
+		compiled, err = r.CompileSpec(spec)
+		if err != nil {
+			return "", err
+		}
+		r.mu.Lock()
+		r.cache[spec.Name] = compiled
+		r.mu.Unlock()
+	}
 
-		// VULN: Include other templates (path traversal)
-		"include": func(path string) string {
-			content, _ := os.ReadFile(filepath.Join(r.templatesDir, path))
-			return string(content)
-		},
+	return compiled.Execute(data)
+}
 
-		// VULN: Write to file from template
-		"writeFile": func(path, content string) string {
-			os.WriteFile(path, []byte(content), 0644) // TAINT SINK
-			return ""
-		},
+// RenderEphemeral compiles and executes spec once, without caching it.
+// This replaces the old RenderString/EvalExpression: spec.Caps is forced
+// to CapNone, so an ephemeral, possibly user-supplied template body can
+// never reach exec, env, or filesystem funcs no matter what the caller set
+// spec.Caps to.
+func (r *Renderer) RenderEphemeral(spec TemplateSpec, data map[string]interface{}) (string, error) {
+	spec.Caps = CapNone
+	compiled, err := r.CompileSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	return compiled.Execute(data)
+}
 
-		// String manipulation (safe)
+// funcMapFor returns the FuncMap spec.Caps permits: a Sprig-like set of
+// pure funcs always present, plus one extra func per capability bit set.
+func funcMapFor(spec TemplateSpec) htmltemplate.FuncMap {
+	fm := htmltemplate.FuncMap{
 		"upper":    strings.ToUpper,
 		"lower":    strings.ToLower,
 		"trim":     strings.TrimSpace,
 		"replace":  strings.Replace,
 		"contains": strings.Contains,
-
-		// VULN: URL for SSRF
-		"fetch": func(url string) string {
-			// Would fetch URL content - SSRF
-			return fmt.Sprintf("[fetch: %s]", url)
-		},
-
-		// VULN: SQL query from template (hypothetical)
-		"query": func(sql string) string {
-			return fmt.Sprintf("[query: %s]", sql)
-		},
-	}
-}
-
-// unsafeTextFuncMap for text/template
-func (r *Renderer) unsafeTextFuncMap() texttemplate.FuncMap {
-	return texttemplate.FuncMap{
-		"exec": func(cmd string, args ...string) string {
-			output, _ := exec.Command(cmd, args...).Output()
-			return string(output)
+		"default": func(def, val interface{}) interface{} {
+			if val == nil {
+				return def
+			}
+			if s, ok := val.(string); ok && s == "" {
+				return def
+			}
+			return val
 		},
-		"readFile": func(path string) string {
-			content, _ := os.ReadFile(path)
-			return string(content)
+		"date": func(layout string, t time.Time) string {
+			return t.Format(layout)
 		},
-		"env":   os.Getenv,
-		"shell": func(cmd string) string {
-			output, _ := exec.Command("sh", "-c", cmd).Output()
-			return string(output)
+		"printf": fmt.Sprintf,
+		"toJson": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
 		},
 	}
-}
 
-// RenderWithIncludes renders a template that can include other templates
-// VULN: Path traversal via include directives
-func (r *Renderer) RenderWithIncludes(templateName string, data map[string]interface{}) (string, error) {
-	// Load main template
-	mainPath := filepath.Join(r.templatesDir, templateName)
-	mainContent, err := os.ReadFile(mainPath)
-	if err != nil {
-		return "", err
+	if spec.Caps.Has(CapEnv) {
+		fm["env"] = os.Getenv
 	}
 
-	// Process include directives
-	// VULN: No sanitization of included paths
-	content := string(mainContent)
-	content = r.processIncludes(content)
+	if spec.Caps.Has(CapExec) {
+		fm["exec"] = func(cmd string, args ...string) string {
+			output, _ := exec.Command(cmd, args...).Output()
+			return string(output)
+		}
+	}
 
-	// Parse and execute
-	tmpl, err := htmltemplate.New(templateName).Funcs(r.unsafeFuncMap()).Parse(content)
-	if err != nil {
-		return "", err
+	if spec.Caps.Has(CapNet) {
+		// fetch is still a stub, same as before CapNet existed: no HTTP
+		// client is wired in here, so granting CapNet can't be used to
+		// make this engine issue a request on a template author's behalf.
+		fm["fetch"] = func(url string) string {
+			return fmt.Sprintf("[fetch: %s]", url)
+		}
 	}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", err
+	if spec.Caps.Has(CapFS) {
+		fm["readFile"] = func(path string) (string, error) {
+			resolved, err := resolveUnderRoot(spec.FSRoot, path)
+			if err != nil {
+				return "", err
+			}
+			content, err := os.ReadFile(resolved)
+			return string(content), err
+		}
 	}
 
-	return buf.String(), nil
+	return fm
 }
 
-// processIncludes handles {{include "path"}} directives
-// VULN: Path traversal in includes
-func (r *Renderer) processIncludes(content string) string {
-	// Simple include processing (vulnerable)
-	// Looks for {{include "..."}} and replaces with file content
-	for strings.Contains(content, "{{include") {
-		start := strings.Index(content, "{{include")
-		if start == -1 {
-			break
+// rejectForeignDefines fails if source {{define}}s or {{template}}s any
+// name other than allowedName, which would otherwise let one spec's
+// content reach into - or collide with - a template compiled under a
+// different name and possibly different Caps.
+func rejectForeignDefines(source, allowedName string) error {
+	for _, m := range defineRe.FindAllStringSubmatch(source, -1) {
+		if m[1] != allowedName {
+			return fmt.Errorf("template defines %q, which escapes this spec's allowlist (only %q is permitted)", m[1], allowedName)
 		}
-
-		end := strings.Index(content[start:], "}}")
-		if end == -1 {
-			break
+	}
+	for _, m := range templateRe.FindAllStringSubmatch(source, -1) {
+		if m[1] != allowedName {
+			return fmt.Errorf("template references %q, which escapes this spec's allowlist (only %q is permitted)", m[1], allowedName)
 		}
+	}
+	return nil
+}
+
+// expandIncludes replaces every {{include "path"}} in source with the
+// contents of path, resolved under fsRoot via resolveUnderRoot, expanding
+// recursively up to maxIncludeDepth to stop an include cycle/bomb.
+func expandIncludes(source, fsRoot string, depth int) (string, error) {
+	matches := includeRe.FindAllStringSubmatchIndex(source, -1)
+	if len(matches) == 0 {
+		return source, nil
+	}
+	if depth >= maxIncludeDepth {
+		return "", fmt.Errorf("include depth exceeds %d: possible include bomb", maxIncludeDepth)
+	}
+	if fsRoot == "" {
+		return "", fmt.Errorf("template uses include but no FSRoot is configured")
+	}
 
-		directive := content[start : start+end+2]
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		relPath := source[m[2]:m[3]]
 
-		// Extract path from directive
-		pathStart := strings.Index(directive, "\"")
-		pathEnd := strings.LastIndex(directive, "\"")
-		if pathStart == -1 || pathEnd == -1 || pathStart == pathEnd {
-			break
+		resolved, err := resolveUnderRoot(fsRoot, relPath)
+		if err != nil {
+			return "", fmt.Errorf("include %q: %w", relPath, err)
+		}
+		content, err := os.ReadFile(resolved)
+		if err != nil {
+			return "", fmt.Errorf("include %q: %w", relPath, err)
 		}
 
-		includePath := directive[pathStart+1 : pathEnd]
-
-		// VULN: Path traversal - includePath not sanitized
-		fullPath := filepath.Join(r.templatesDir, includePath)
-		includeContent, err := os.ReadFile(fullPath) // TAINT SINK
+		expanded, err := expandIncludes(string(content), fsRoot, depth+1)
 		if err != nil {
-			includeContent = []byte(fmt.Sprintf("[include error: %s]", err))
+			return "", err
 		}
 
-		content = strings.Replace(content, directive, string(includeContent), 1)
+		out.WriteString(source[last:start])
+		out.WriteString(expanded)
+		last = end
 	}
-
-	return content
+	out.WriteString(source[last:])
+	return out.String(), nil
 }
 
-// CompileTemplate compiles a template and stores it
-// VULN: User-controlled template stored
-func (r *Renderer) CompileTemplate(name, content string) error {
-	tmpl, err := htmltemplate.New(name).Funcs(r.unsafeFuncMap()).Parse(content)
+// resolveUnderRoot joins rel onto root, resolves both through symlinks,
+// and confirms the result still falls under root - the same
+// EvalSymlinks+HasPrefix pattern used to stop path traversal via a
+// template name or an {{include}} target.
+func resolveUnderRoot(root, rel string) (string, error) {
+	absRoot, err := filepath.Abs(root)
 	if err != nil {
-		return err
+		return "", err
+	}
+	rootResolved, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return "", fmt.Errorf("resolve root %q: %w", root, err)
 	}
 
-	r.cache[name] = tmpl
-	return nil
+	full := filepath.Join(absRoot, rel)
+	resolved, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		return "", fmt.Errorf("resolve %q: %w", rel, err)
+	}
+
+	if resolved != rootResolved && !strings.HasPrefix(resolved, rootResolved+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes root %q", rel, root)
+	}
+
+	return resolved, nil
 }
 
 // SaveTemplate saves a template to the filesystem
@@ -271,7 +376,7 @@ func (r *Renderer) SaveTemplate(name, content string) error {
 // ListTemplates lists available templates
 // VULN: Information disclosure
 func (r *Renderer) ListTemplates() ([]string, error) {
-	var templates []string
+	var names []string
 
 	err := filepath.Walk(r.templatesDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -279,24 +384,10 @@ func (r *Renderer) ListTemplates() ([]string, error) {
 		}
 		if !info.IsDir() {
 			relPath, _ := filepath.Rel(r.templatesDir, path)
-			templates = append(templates, relPath)
+			names = append(names, relPath)
 		}
 		return nil
 	})
 
-	return templates, err
-}
-
-// EvalExpression evaluates an expression in template context
-// VULN: Code injection via expression
-func (r *Renderer) EvalExpression(expr string, data map[string]interface{}) (interface{}, error) {
-	// Wrap expression in template
-	templateStr := fmt.Sprintf("{{%s}}", expr)
-
-	result, err := r.RenderString(templateStr, data)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return names, err
 }
@@ -0,0 +1,205 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/project-anarchy/go_notifications/internal/storage/templaterepo"
+)
+
+// ChannelVariant overrides Subject and/or Message for one channel; a
+// field left empty falls back to the enclosing LocaleVariant's own
+// Subject/Message, the same layering Argo's notifications engine uses to
+// combine a base message with per-service overrides.
+type ChannelVariant struct {
+	Subject string `yaml:"subject,omitempty"`
+	Message string `yaml:"message,omitempty"`
+}
+
+// LocaleVariant is one localized rendering of a named template: a base
+// Subject/Message plus an optional override block per channel. Engine.Render
+// picks the override for the requested channel, if any, then escapes the
+// result the way that channel requires - see Engine.Render.
+type LocaleVariant struct {
+	Subject string `yaml:"subject"`
+	Message string `yaml:"message"`
+
+	Slack   *ChannelVariant `yaml:"slack,omitempty"`
+	Email   *ChannelVariant `yaml:"email,omitempty"`
+	Webhook *ChannelVariant `yaml:"webhook,omitempty"`
+}
+
+// channelOverride returns v's override block for channel, if it has one.
+func (v LocaleVariant) channelOverride(channel string) *ChannelVariant {
+	switch channel {
+	case "slack":
+		return v.Slack
+	case "email":
+		return v.Email
+	case "webhook":
+		return v.Webhook
+	default:
+		return nil
+	}
+}
+
+// TemplateDef is a named template with one LocaleVariant per locale code.
+// "default" is used when the requested locale has no entry of its own.
+type TemplateDef map[string]LocaleVariant
+
+// Variant picks the best LocaleVariant for locale, falling back to
+// "default", then to an arbitrary one of d's variants (map iteration
+// order, not the order they appear in the YAML).
+func (d TemplateDef) Variant(locale string) (LocaleVariant, error) {
+	if locale != "" {
+		if v, ok := d[locale]; ok {
+			return v, nil
+		}
+	}
+	if v, ok := d["default"]; ok {
+		return v, nil
+	}
+	for _, v := range d {
+		return v, nil
+	}
+	return LocaleVariant{}, fmt.Errorf("template has no locale variants defined")
+}
+
+// TemplateStore loads named templates, independent of where they're
+// persisted.
+type TemplateStore interface {
+	Get(ctx context.Context, name string) (TemplateDef, error)
+	List(ctx context.Context) ([]string, error)
+}
+
+// FSTemplateStore loads templates from "<dir>/<name>.yaml" files, walking
+// subdirectories so a name may itself contain "/" (e.g. "alerts/high_cpu").
+// Parsed definitions are cached by name, guarded by mu, so a hot path
+// doesn't re-read and re-parse YAML on every Get; Invalidate evicts one
+// entry, for WatchingTemplateStore's fsnotify handler to call on change.
+type FSTemplateStore struct {
+	dir string
+
+	mu    sync.RWMutex
+	cache map[string]TemplateDef
+}
+
+// NewFSTemplateStore creates a store rooted at dir.
+func NewFSTemplateStore(dir string) *FSTemplateStore {
+	return &FSTemplateStore{dir: dir, cache: make(map[string]TemplateDef)}
+}
+
+// resolve maps name to "<dir>/<name>.yaml", confirming via filepath.Rel
+// that the result still falls under dir, so "../../etc/passwd" as a
+// template name can't be used to read arbitrary files.
+func (s *FSTemplateStore) resolve(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+	full := filepath.Join(s.dir, name+".yaml")
+	rel, err := filepath.Rel(s.dir, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+	return full, nil
+}
+
+// Get loads and parses "<name>.yaml", serving from cache on a hit.
+func (s *FSTemplateStore) Get(ctx context.Context, name string) (TemplateDef, error) {
+	s.mu.RLock()
+	def, ok := s.cache[name]
+	s.mu.RUnlock()
+	if ok {
+		return def, nil
+	}
+
+	path, err := s.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load template %q: %w", name, err)
+	}
+
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("parse template %q: %w", name, err)
+	}
+
+	s.mu.Lock()
+	s.cache[name] = def
+	s.mu.Unlock()
+	return def, nil
+}
+
+// Invalidate evicts name from the cache, so the next Get reloads it from
+// disk. Safe to call concurrently with Get.
+func (s *FSTemplateStore) Invalidate(name string) {
+	s.mu.Lock()
+	delete(s.cache, name)
+	s.mu.Unlock()
+}
+
+// List returns the names of every "*.yaml" template file under dir,
+// walked recursively, with nested files named "sub/dir/name".
+func (s *FSTemplateStore) List(ctx context.Context) ([]string, error) {
+	var names []string
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".yaml") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return nil
+		}
+		names = append(names, strings.TrimSuffix(filepath.ToSlash(rel), ".yaml"))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DBTemplateStore loads templates from the templates table. Each row's
+// content column holds the same locale-keyed YAML that FSTemplateStore
+// reads from disk, so the two stores are interchangeable.
+type DBTemplateStore struct {
+	repo *templaterepo.Repo
+}
+
+// NewDBTemplateStore creates a store backed by repo.
+func NewDBTemplateStore(repo *templaterepo.Repo) *DBTemplateStore {
+	return &DBTemplateStore{repo: repo}
+}
+
+// Get loads and parses the named row.
+func (s *DBTemplateStore) Get(ctx context.Context, name string) (TemplateDef, error) {
+	row, err := s.repo.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("load template %q: %w", name, err)
+	}
+
+	var def TemplateDef
+	if err := yaml.Unmarshal([]byte(row.Content), &def); err != nil {
+		return nil, fmt.Errorf("parse template %q: %w", name, err)
+	}
+	return def, nil
+}
+
+// List returns every template name in the table.
+func (s *DBTemplateStore) List(ctx context.Context) ([]string, error) {
+	return s.repo.List(ctx)
+}
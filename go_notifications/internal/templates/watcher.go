@@ -0,0 +1,138 @@
+package templates
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// TemplateEvent is emitted on WatchingRenderer.Events whenever a
+// filesystem change causes a cache invalidation or reload, so operators
+// can wire up metrics/logging around template rollouts.
+type TemplateEvent struct {
+	Name string
+	Op   string // "invalidated", "reloaded", "evicted", "reload_error"
+	Err  error
+}
+
+// WatchingRenderer wraps a Renderer with an fsnotify watch on its
+// templatesDir: edits to template files on disk invalidate (or, with
+// PreloadAll, eagerly recompile) the corresponding cache entry, so
+// operators no longer have to restart the service to pick up a template
+// change.
+type WatchingRenderer struct {
+	*Renderer
+
+	watcher    *fsnotify.Watcher
+	preloadAll bool
+	events     chan TemplateEvent
+	done       chan struct{}
+}
+
+// WatchingOption configures NewWatchingRenderer.
+type WatchingOption func(*WatchingRenderer)
+
+// PreloadAll causes every Write/Create/Rename event to eagerly recompile
+// the changed template instead of merely evicting it, trading a slightly
+// slower watch loop for surfacing a bad template's parse error on Events
+// immediately rather than on the next Render call.
+func PreloadAll() WatchingOption {
+	return func(wr *WatchingRenderer) { wr.preloadAll = true }
+}
+
+// NewWatchingRenderer wraps r, watching r's templatesDir for changes. r
+// is the same Renderer callers elsewhere (e.g. api.Handlers) hold, so a
+// template edit on disk is reflected in every caller's next Render, not
+// just a private copy. Call Close to stop the watcher goroutine.
+func NewWatchingRenderer(r *Renderer, opts ...WatchingOption) (*WatchingRenderer, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start template watcher: %w", err)
+	}
+	if err := watcher.Add(r.templatesDir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", r.templatesDir, err)
+	}
+
+	wr := &WatchingRenderer{
+		Renderer: r,
+		watcher:  watcher,
+		events:   make(chan TemplateEvent, 16),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(wr)
+	}
+
+	go wr.watch()
+	return wr, nil
+}
+
+// Events returns the channel TemplateEvent values are published on. The
+// channel is buffered but not drained by WatchingRenderer itself; a
+// caller that doesn't read it just stops seeing new events once it
+// fills rather than blocking the watch loop (see emit).
+func (wr *WatchingRenderer) Events() <-chan TemplateEvent {
+	return wr.events
+}
+
+// Close stops the underlying fsnotify watcher and its goroutine.
+func (wr *WatchingRenderer) Close() error {
+	close(wr.done)
+	return wr.watcher.Close()
+}
+
+func (wr *WatchingRenderer) watch() {
+	for {
+		select {
+		case <-wr.done:
+			return
+		case event, ok := <-wr.watcher.Events:
+			if !ok {
+				return
+			}
+			wr.handle(event)
+		case err, ok := <-wr.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("templates: watcher error: %v", err)
+		}
+	}
+}
+
+func (wr *WatchingRenderer) handle(event fsnotify.Event) {
+	name, err := filepath.Rel(wr.templatesDir, event.Name)
+	if err != nil {
+		name = filepath.Base(event.Name)
+	}
+
+	switch {
+	case event.Op&fsnotify.Remove != 0:
+		wr.Reload(name)
+		wr.emit(TemplateEvent{Name: name, Op: "evicted"})
+
+	case event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0:
+		wr.Reload(name)
+		if !wr.preloadAll {
+			wr.emit(TemplateEvent{Name: name, Op: "invalidated"})
+			return
+		}
+		if _, err := wr.compiled(name); err != nil {
+			wr.emit(TemplateEvent{Name: name, Op: "reload_error", Err: err})
+			return
+		}
+		wr.emit(TemplateEvent{Name: name, Op: "reloaded"})
+	}
+}
+
+// emit publishes ev, dropping it rather than blocking the watch loop if
+// Events isn't being drained fast enough.
+func (wr *WatchingRenderer) emit(ev TemplateEvent) {
+	select {
+	case wr.events <- ev:
+	default:
+	}
+}
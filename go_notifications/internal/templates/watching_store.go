@@ -0,0 +1,115 @@
+package templates
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchingTemplateStore wraps an FSTemplateStore with an fsnotify watch
+// over its directory tree: edits to a template file on disk invalidate
+// the corresponding cache entry, the same role WatchingRenderer plays for
+// Renderer. fsnotify watches aren't recursive, so New walks store.dir once
+// at startup and adds a watch on every subdirectory found, matching
+// FSTemplateStore's own nested-name support.
+type WatchingTemplateStore struct {
+	*FSTemplateStore
+
+	watcher *fsnotify.Watcher
+	events  chan TemplateEvent
+	done    chan struct{}
+}
+
+// NewWatchingTemplateStore wraps store, watching every directory under
+// store.dir for changes. Call Close to stop the watcher goroutine.
+func NewWatchingTemplateStore(store *FSTemplateStore) (*WatchingTemplateStore, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start template store watcher: %w", err)
+	}
+	if err := addTreeWatch(watcher, store.dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", store.dir, err)
+	}
+
+	ws := &WatchingTemplateStore{
+		FSTemplateStore: store,
+		watcher:         watcher,
+		events:          make(chan TemplateEvent, 16),
+		done:            make(chan struct{}),
+	}
+	go ws.watch()
+	return ws, nil
+}
+
+// Events returns the channel TemplateEvent values are published on, the
+// same semantics as WatchingRenderer.Events - buffered but not drained by
+// WatchingTemplateStore itself.
+func (ws *WatchingTemplateStore) Events() <-chan TemplateEvent {
+	return ws.events
+}
+
+// Close stops the underlying fsnotify watcher and its goroutine.
+func (ws *WatchingTemplateStore) Close() error {
+	close(ws.done)
+	return ws.watcher.Close()
+}
+
+func (ws *WatchingTemplateStore) watch() {
+	for {
+		select {
+		case <-ws.done:
+			return
+		case event, ok := <-ws.watcher.Events:
+			if !ok {
+				return
+			}
+			ws.handle(event)
+		case err, ok := <-ws.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("templates: store watcher error: %v", err)
+		}
+	}
+}
+
+func (ws *WatchingTemplateStore) handle(event fsnotify.Event) {
+	if !strings.HasSuffix(event.Name, ".yaml") {
+		return
+	}
+	rel, err := filepath.Rel(ws.dir, event.Name)
+	if err != nil {
+		rel = filepath.Base(event.Name)
+	}
+	name := strings.TrimSuffix(filepath.ToSlash(rel), ".yaml")
+
+	ws.Invalidate(name)
+	ws.emit(TemplateEvent{Name: name, Op: "invalidated"})
+}
+
+func (ws *WatchingTemplateStore) emit(ev TemplateEvent) {
+	select {
+	case ws.events <- ev:
+	default:
+	}
+}
+
+// addTreeWatch adds a watch on root and every directory beneath it, since
+// a single fsnotify.Watcher.Add only watches one directory's immediate
+// contents.
+func addTreeWatch(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
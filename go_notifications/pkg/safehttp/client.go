@@ -0,0 +1,192 @@
+package safehttp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Client is an SSRF-hardened HTTP client built from a Policy. Its Do,
+// Get, and Post methods mirror the subset of *http.Client's signature
+// callers in this repo actually use, so it can be dropped into a struct
+// field that used to hold a bare *http.Client.
+type Client struct {
+	policy Policy
+	http   *http.Client
+}
+
+// NewClient builds a Client enforcing policy. DNS resolution and dialing
+// happen through a single net.Dialer.Control callback that inspects the
+// resolved address right before the connect() syscall - the same
+// address Go is about to use, not a separate lookup that could have
+// changed (DNS rebinding / TOCTOU). CheckRedirect re-validates scheme and
+// domain allow/deny on every redirect; the per-connection Control check
+// then re-validates the address for the redirected request too.
+func NewClient(policy Policy) *Client {
+	if len(policy.AllowedSchemes) == 0 {
+		policy.AllowedSchemes = []string{"https"}
+	}
+	if policy.MaxRedirects <= 0 {
+		policy.MaxRedirects = 3
+	}
+	if policy.Timeout <= 0 {
+		policy.Timeout = 15 * time.Second
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: addrControl,
+	}
+	transport := &http.Transport{
+		DialContext: dialer.DialContext,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: !policy.VerifyCertificates,
+		},
+	}
+
+	httpClient := &http.Client{
+		Timeout:   policy.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= policy.MaxRedirects {
+				return fmt.Errorf("safehttp: stopped after %d redirects", policy.MaxRedirects)
+			}
+			return validateRequestURL(policy, req.URL)
+		},
+	}
+
+	return &Client{policy: policy, http: httpClient}
+}
+
+// addrControl is the net.Dialer.Control callback shared by every Client:
+// address is the literal IP:port the dialer resolved and is about to
+// connect() to, so checking it here - rather than re-resolving the
+// hostname - can't be fooled by a second lookup returning a different
+// answer than the one actually being dialed.
+func addrControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("safehttp: %w", err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("safehttp: could not parse resolved address %q", address)
+	}
+	if isInternalIP(ip) {
+		return fmt.Errorf("safehttp: connection to %s blocked: internal address", ip)
+	}
+	return nil
+}
+
+// validateRequestURL checks u's scheme against policy.AllowedSchemes and
+// its host against DeniedDomains/AllowedDomains. Address-level checks
+// happen separately in addrControl, which runs per connection attempt
+// (including every redirect hop), since they require resolving the host.
+func validateRequestURL(policy Policy, u *url.URL) error {
+	allowed := false
+	for _, scheme := range policy.AllowedSchemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("safehttp: scheme %q not allowed", u.Scheme)
+	}
+
+	host := u.Hostname()
+	for _, denied := range policy.DeniedDomains {
+		if domainMatches(host, denied) {
+			return fmt.Errorf("safehttp: host %q is denied", host)
+		}
+	}
+	if len(policy.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, allowedHost := range policy.AllowedDomains {
+		if domainMatches(host, allowedHost) {
+			return nil
+		}
+	}
+	return fmt.Errorf("safehttp: host %q is not in the allow-list", host)
+}
+
+// domainMatches reports whether host matches pattern, which may be an
+// exact hostname, a CIDR (for IP literals), or a leading-dot suffix
+// like ".example.com" matching any subdomain.
+func domainMatches(host, pattern string) bool {
+	if strings.HasPrefix(pattern, ".") {
+		return strings.HasSuffix(host, pattern) || strings.EqualFold(host, strings.TrimPrefix(pattern, "."))
+	}
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		if ip := net.ParseIP(host); ip != nil {
+			return network.Contains(ip)
+		}
+		return false
+	}
+	return strings.EqualFold(host, pattern)
+}
+
+// Do validates req.URL against the Client's policy, issues the request,
+// logs it under policy.Purpose, and - if MaxResponseBytes is set - wraps
+// the response body in a reader that stops yielding data past the cap.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := validateRequestURL(c.policy, req.URL); err != nil {
+		log.Printf("safehttp[%s]: blocked %s %s: %v", c.policy.Purpose, req.Method, req.URL, err)
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		log.Printf("safehttp[%s]: %s %s failed after %s: %v", c.policy.Purpose, req.Method, req.URL, time.Since(start), err)
+		return nil, err
+	}
+
+	log.Printf("safehttp[%s]: %s %s -> %d (%s)", c.policy.Purpose, req.Method, req.URL, resp.StatusCode, time.Since(start))
+	if c.policy.MaxResponseBytes > 0 {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(resp.Body, c.policy.MaxResponseBytes), resp.Body}
+	}
+	return resp, nil
+}
+
+// HTTPClient returns the underlying *http.Client, for callers that need
+// to hand it to code expecting that concrete type (e.g. as a drop-in for
+// an existing unprotected http.Client field). The SSRF protections built
+// into its Transport (the internal-address block) and CheckRedirect (the
+// scheme/domain checks on every redirect hop) still apply. Do's
+// scheme/domain check of the initial request URL, its Purpose-based audit
+// logging and its response-size cap are unavailable through this path,
+// since those live in Client.Do rather than the transport.
+func (c *Client) HTTPClient() *http.Client {
+	return c.http
+}
+
+// Get issues a GET request through Do.
+func (c *Client) Get(targetURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Post issues a POST request through Do.
+func (c *Client) Post(targetURL, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, targetURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
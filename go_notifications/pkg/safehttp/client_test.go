@@ -0,0 +1,185 @@
+package safehttp
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestIsInternalIP(t *testing.T) {
+	cases := []struct {
+		ip       string
+		internal bool
+	}{
+		{"10.0.0.1", true},
+		{"172.16.5.1", true},
+		{"192.168.1.1", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true}, // cloud metadata
+		{"0.0.0.0", true},
+		{"224.0.0.1", true}, // multicast
+		{"::1", true},
+		{"fc00::1", true}, // unique-local
+		{"8.8.8.8", false},
+		{"1.1.1.1", false},
+		{"93.184.216.34", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.ip, func(t *testing.T) {
+			ip := net.ParseIP(c.ip)
+			if ip == nil {
+				t.Fatalf("test case has unparseable IP %q", c.ip)
+			}
+			if got := isInternalIP(ip); got != c.internal {
+				t.Errorf("isInternalIP(%s) = %v, want %v", c.ip, got, c.internal)
+			}
+		})
+	}
+}
+
+func TestDomainMatches(t *testing.T) {
+	cases := []struct {
+		host, pattern string
+		want          bool
+	}{
+		{"example.com", "example.com", true},
+		{"EXAMPLE.com", "example.com", true},
+		{"evil.com", "example.com", false},
+		{"api.example.com", ".example.com", true},
+		{"example.com", ".example.com", true},
+		{"notexample.com", ".example.com", false},
+		{"evilexample.com", "example.com", false},
+		{"10.0.0.5", "10.0.0.0/8", true},
+		{"8.8.8.8", "10.0.0.0/8", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.host+"/"+c.pattern, func(t *testing.T) {
+			if got := domainMatches(c.host, c.pattern); got != c.want {
+				t.Errorf("domainMatches(%q, %q) = %v, want %v", c.host, c.pattern, got, c.want)
+			}
+		})
+	}
+}
+
+func TestValidateRequestURL(t *testing.T) {
+	policy := Policy{
+		AllowedSchemes: []string{"https"},
+		AllowedDomains: []string{"api.example.com", ".partner.example.com"},
+		DeniedDomains:  []string{"blocked.partner.example.com"},
+	}
+
+	cases := []struct {
+		name    string
+		rawURL  string
+		wantErr bool
+	}{
+		{"allowed host", "https://api.example.com/v1", false},
+		{"allowed wildcard subdomain", "https://sub.partner.example.com/v1", false},
+		{"denied overrides allowed wildcard", "https://blocked.partner.example.com/v1", true},
+		{"host not on allow-list", "https://evil.com/", true},
+		{"disallowed scheme", "http://api.example.com/", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			u, err := url.Parse(c.rawURL)
+			if err != nil {
+				t.Fatalf("failed to parse test URL: %v", err)
+			}
+			err = validateRequestURL(policy, u)
+			if (err != nil) != c.wantErr {
+				t.Errorf("validateRequestURL(%s) error = %v, wantErr %v", c.rawURL, err, c.wantErr)
+			}
+		})
+	}
+}
+
+// TestClientDo_BlocksRedirectToDeniedHost verifies that CheckRedirect
+// re-validates every hop, not just the initial request URL - a server
+// this Client trusted at request time can't use a 3xx response to smuggle
+// the request onward to a host the policy denies.
+func TestClientDo_BlocksRedirectToDeniedHost(t *testing.T) {
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer blocked.Close()
+
+	// Both test servers listen on 127.0.0.1, so the redirect target is
+	// addressed as "localhost" to give it a host name of its own to deny.
+	blockedURL, _ := url.Parse(blocked.URL)
+	blockedURL.Host = "localhost:" + blockedURL.Port()
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, blockedURL.String(), http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	policy := Policy{
+		AllowedSchemes: []string{"http"},
+		DeniedDomains:  []string{blockedURL.Hostname()},
+	}
+	client := NewClient(policy)
+	// addrControl refuses loopback addresses, which would fail the first
+	// request before any redirect; a plain transport leaves CheckRedirect
+	// as the check under test.
+	client.http.Transport = &http.Transport{}
+
+	_, err := client.Get(redirector.URL)
+	if err == nil {
+		t.Fatal("expected redirect to a denied host to be blocked, got nil error")
+	}
+}
+
+// TestClientDo_StopsAfterMaxRedirects verifies the redirect count is
+// actually enforced rather than left to net/http's default of 10.
+func TestClientDo_StopsAfterMaxRedirects(t *testing.T) {
+	var server *httptest.Server
+	hops := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops++
+		http.Redirect(w, r, server.URL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	policy := Policy{
+		AllowedSchemes: []string{"http"},
+		MaxRedirects:   2,
+	}
+	client := NewClient(policy)
+	// addrControl refuses loopback addresses; a plain transport lets the
+	// redirect loop actually run against the httptest server.
+	client.http.Transport = &http.Transport{}
+
+	_, err := client.Get(server.URL)
+	if err == nil {
+		t.Fatal("expected an infinite redirect loop to be stopped, got nil error")
+	}
+	if hops != policy.MaxRedirects {
+		t.Errorf("server saw %d requests, want %d (the initial request plus %d redirects followed)", hops, policy.MaxRedirects, policy.MaxRedirects-1)
+	}
+}
+
+func TestValidateRequestURL_EmptyAllowListPermitsAnyNonDeniedHost(t *testing.T) {
+	policy := Policy{AllowedSchemes: []string{"https"}}
+	u, _ := url.Parse("https://anything.example.org/")
+	if err := validateRequestURL(policy, u); err != nil {
+		t.Errorf("expected empty AllowedDomains to permit any host, got error: %v", err)
+	}
+}
+
+func TestDomainMatches_InvalidCIDRFallsBackToExactMatch(t *testing.T) {
+	// "not-a-cidr" fails net.ParseCIDR, so domainMatches should fall
+	// through to a plain case-insensitive hostname comparison instead of
+	// panicking or silently matching everything.
+	if domainMatches("not-a-cidr", "not-a-cidr") != true {
+		t.Error("expected exact-match fallback for a non-CIDR pattern")
+	}
+	if domainMatches("other-host", "not-a-cidr") != false {
+		t.Error("expected non-CIDR pattern to not match an unrelated host")
+	}
+}
+
@@ -0,0 +1,25 @@
+package safehttp
+
+import "net"
+
+// isInternalIP reports whether ip falls into a private, loopback,
+// link-local (including the 169.254.169.254 cloud metadata address,
+// caught by IsLinkLocalUnicast), IPv6 fc00::/7 unique-local (covered by
+// IsPrivate since Go 1.17), multicast, unspecified, or 0.0.0.0/8
+// ("this network") range.
+func isInternalIP(ip net.IP) bool {
+	if ip.IsPrivate() ||
+		ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() {
+		return true
+	}
+
+	if v4 := ip.To4(); v4 != nil && v4[0] == 0 {
+		return true // 0.0.0.0/8
+	}
+
+	return false
+}
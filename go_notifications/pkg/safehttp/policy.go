@@ -0,0 +1,73 @@
+// Package safehttp wraps *http.Client with an SSRF-hardened policy:
+// scheme and domain allow/deny lists, internal-address blocking that's
+// re-checked on every redirect and at the moment of connect (so a DNS
+// answer that changes between validation and dial can't slip through),
+// response-size caps, and structured audit logging of every outbound
+// request by Purpose.
+package safehttp
+
+import "time"
+
+// Policy controls what a Client built by NewClient is allowed to
+// request. The zero value is not safe to use directly - start from
+// DefaultPolicy.
+type Policy struct {
+	// Purpose labels this Client's audit log lines (e.g. "slack",
+	// "webhook", "proxy"), so a destination blocked or a request made
+	// can be traced back to the feature that issued it.
+	Purpose string
+
+	// AllowedSchemes restricts request URLs to these schemes. Defaults
+	// to {"https"} if empty - callers that need to allow plain HTTP
+	// (e.g. webhook delivery to an operator-configured endpoint) must
+	// opt in explicitly.
+	AllowedSchemes []string
+
+	// AllowedDomains, if non-empty, restricts requests to these
+	// hostnames (exact match, or a leading-dot suffix such as
+	// ".example.com" matching that domain and its subdomains) or CIDRs.
+	// Checked after DeniedDomains.
+	// Empty means any host is allowed, subject to the internal-address
+	// block below.
+	AllowedDomains []string
+
+	// DeniedDomains is checked before AllowedDomains and always wins,
+	// so an operator can block a specific host even under an otherwise
+	// permissive AllowedDomains list.
+	DeniedDomains []string
+
+	// MaxRedirects bounds how many redirects Client.Do follows before
+	// giving up. Defaults to 3.
+	MaxRedirects int
+
+	// MaxResponseBytes caps how much of a response body callers can
+	// read via resp.Body; past the cap, Read returns io.EOF rather than
+	// the rest of the body. 0 means unlimited.
+	MaxResponseBytes int64
+
+	// Timeout bounds the whole request (connect, redirects, reading the
+	// response). Defaults to 15s.
+	Timeout time.Duration
+
+	// VerifyCertificates disables TLS certificate verification when
+	// false. DefaultPolicy sets it to true (verification on); NewClient
+	// does not default it, so a Policy that leaves it unset skips
+	// verification. Only ever flip this
+	// for a policy that talks to a known internal CA, never to silence
+	// an untrusted cert on an arbitrary destination.
+	VerifyCertificates bool
+}
+
+// DefaultPolicy returns a safe-by-default policy for purpose: HTTPS
+// only, certificates verified, redirects limited, no host allow-list
+// beyond the built-in internal-address blocking.
+func DefaultPolicy(purpose string) Policy {
+	return Policy{
+		Purpose:            purpose,
+		AllowedSchemes:     []string{"https"},
+		MaxRedirects:       3,
+		MaxResponseBytes:   10 << 20, // 10 MiB
+		Timeout:            15 * time.Second,
+		VerifyCertificates: true,
+	}
+}
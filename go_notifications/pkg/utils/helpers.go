@@ -7,11 +7,12 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"html"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,62 +20,6 @@ import (
 	"strings"
 )
 
-// ToCSV converts data to CSV format
-func ToCSV(data []map[string]interface{}) []byte {
-	if len(data) == 0 {
-		return []byte{}
-	}
-
-	var sb strings.Builder
-
-	// Get headers from first row
-	headers := make([]string, 0)
-	for key := range data[0] {
-		headers = append(headers, key)
-	}
-	sb.WriteString(strings.Join(headers, ",") + "\n")
-
-	// Write data rows
-	for _, row := range data {
-		values := make([]string, len(headers))
-		for i, header := range headers {
-			if val, ok := row[header]; ok {
-				// VULN: No CSV escaping - injection possible
-				values[i] = fmt.Sprintf("%v", val)
-			}
-		}
-		sb.WriteString(strings.Join(values, ",") + "\n")
-	}
-
-	return []byte(sb.String())
-}
-
-// ToXML converts data to XML format
-func ToXML(data []map[string]interface{}) []byte {
-	type Item struct {
-		XMLName xml.Name
-		Content string `xml:",chardata"`
-	}
-
-	type Root struct {
-		XMLName xml.Name `xml:"notifications"`
-		Items   []interface{}
-	}
-
-	root := Root{}
-	for _, item := range data {
-		for key, value := range item {
-			root.Items = append(root.Items, Item{
-				XMLName: xml.Name{Local: key},
-				Content: fmt.Sprintf("%v", value),
-			})
-		}
-	}
-
-	output, _ := xml.MarshalIndent(root, "", "  ")
-	return output
-}
-
 // HashPassword creates a password hash
 // VULN: Uses MD5 which is cryptographically weak
 func HashPassword(password string) string {
@@ -114,11 +59,23 @@ func ValidateEmail(email string) bool {
 	return matched
 }
 
-// ValidateURL performs URL validation
-// VULN: Doesn't check for internal IPs or schemes
-func ValidateURL(url string) bool {
-	// VULN: Only checks if URL is non-empty
-	return len(url) > 0 && (strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://"))
+// ValidateURL performs URL validation: it requires an http/https scheme and
+// rejects a URL whose host is, or resolves to, an internal address.
+func ValidateURL(rawURL string) bool {
+	if rawURL == "" {
+		return false
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return false
+	}
+	if parsed.Hostname() == "" {
+		return false
+	}
+	return !resolvesToInternalIP(parsed.Hostname())
 }
 
 // ReadFileContents reads a file
@@ -148,11 +105,15 @@ func ExecuteCommandWithArgs(cmd string, args ...string) (string, error) {
 	return string(output), err
 }
 
-// DownloadFile downloads a file from URL
-// VULN: SSRF
-func DownloadFile(url, savePath string) error {
-	// VULN: No URL validation - SSRF possible
-	resp, err := http.Get(url) // TAINT SINK
+// DownloadFile downloads a file from targetURL, rejecting it (and any
+// redirect it may later issue) if it is not a valid, non-internal address.
+// VULN: savePath is still written without any path validation.
+func DownloadFile(targetURL, savePath string) error {
+	if !ValidateURL(targetURL) {
+		return fmt.Errorf("refusing to download from %q: failed URL validation", targetURL)
+	}
+
+	resp, err := internalHTTPClient.Get(targetURL)
 	if err != nil {
 		return err
 	}
@@ -221,32 +182,33 @@ func SafeJoin(base string, paths ...string) string {
 	return result
 }
 
-// IsInternalIP checks if an IP is internal
-// Not actually used anywhere - dead code
+// IsInternalIP reports whether ip (IPv4 or IPv6) falls into a private,
+// loopback, link-local (which covers the 169.254.169.254 cloud metadata
+// address), multicast, or unspecified range. An unparseable ip is treated
+// as internal, so callers fail closed rather than open.
 func IsInternalIP(ip string) bool {
-	internalPrefixes := []string{
-		"10.",
-		"172.16.", "172.17.", "172.18.", "172.19.",
-		"172.20.", "172.21.", "172.22.", "172.23.",
-		"172.24.", "172.25.", "172.26.", "172.27.",
-		"172.28.", "172.29.", "172.30.", "172.31.",
-		"192.168.",
-		"127.",
-		"169.254.",
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return true
 	}
+	return parsed.IsPrivate() ||
+		parsed.IsLoopback() ||
+		parsed.IsLinkLocalUnicast() ||
+		parsed.IsLinkLocalMulticast() ||
+		parsed.IsMulticast() ||
+		parsed.IsUnspecified()
+}
 
-	for _, prefix := range internalPrefixes {
-		if strings.HasPrefix(ip, prefix) {
-			return true
-		}
+// SendHTTPRequest sends an HTTP request to targetURL, which must pass
+// ValidateURL; the underlying client also re-resolves and re-checks the
+// address on every connection it opens, including ones opened to follow a
+// redirect.
+func SendHTTPRequest(method, targetURL string, headers map[string]string, body string) ([]byte, error) {
+	if !ValidateURL(targetURL) {
+		return nil, fmt.Errorf("refusing to request %q: failed URL validation", targetURL)
 	}
-	return false
-}
 
-// SendHTTPRequest sends an HTTP request
-// VULN: SSRF helper
-func SendHTTPRequest(method, url string, headers map[string]string, body string) ([]byte, error) {
-	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	req, err := http.NewRequest(method, targetURL, strings.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -255,8 +217,7 @@ func SendHTTPRequest(method, url string, headers map[string]string, body string)
 		req.Header.Set(key, value)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req) // TAINT SINK: SSRF
+	resp, err := internalHTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// internalHTTPClient is used by SendHTTPRequest and DownloadFile so both
+// helpers get the same DialContext-level protection: even if the address
+// that passed ValidateURL is backed by a DNS record that changes by the
+// time the connection is actually opened (DNS rebinding), the dialer
+// resolves and checks the address again itself before connecting.
+var internalHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		DialContext: safeDialContext,
+	},
+}
+
+func safeDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolver net.Resolver
+	ips, err := resolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %q: %w", host, err)
+	}
+
+	var dialIP net.IP
+	for _, ip := range ips {
+		if !IsInternalIP(ip.String()) {
+			dialIP = ip
+			break
+		}
+	}
+	if dialIP == nil {
+		return nil, fmt.Errorf("all resolved addresses for %q are internal", host)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(dialIP.String(), port))
+}
+
+// resolvesToInternalIP reports whether any address host resolves to is
+// internal. Used by ValidateURL so a hostname that merely points at an
+// internal address (rather than being a literal internal IP) is rejected
+// too.
+func resolvesToInternalIP(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return IsInternalIP(ip.String())
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		// Unresolvable host: fail closed rather than letting it through.
+		return true
+	}
+	for _, ip := range ips {
+		if IsInternalIP(ip.String()) {
+			return true
+		}
+	}
+	return false
+}